@@ -5,6 +5,13 @@ import (
 	"strings"
 )
 
+/*
+按类型编码一个值，返回不带0x的hex
+	u8到u128按SCALE编码为固定长度的小端字节，例如u64的1编码为0100000000000000
+	以前的版本会去掉高位的0，u64的1编码为01，需要旧格式的调用方请自己处理
+	compact<u32>，compact<u64>，compact<u128>使用正确的模式位，例如64编码为0101
+	string只返回字符串的字节，不带长度前缀
+*/
 func Encode(typeString string, value interface{}) (res string, err error) {
 	var bytes OffsetBytes
 	typeString = strings.ToLower(typeString)
@@ -12,18 +19,13 @@ func Encode(typeString string, value interface{}) (res string, err error) {
 	switch typeString {
 	case "bool":
 		bytes, err = BoolToBytes(value)
-	case "compact<u32>":
-		fallthrough
-	case "u8":
-		fallthrough
-	case "u16":
-		fallthrough
-	case "u32":
-		fallthrough
-	case "u64":
-		fallthrough
-	case "u128":
-		bytes, err = IntToBytes(value)
+	case "compact<u32>", "compact<u64>", "compact<u128>", "u8", "u16", "u32", "u64", "u128":
+		//整数使用EncodeType编码，IntToBytes会去掉高位的0，compact的模式位也不正确
+		var data []byte
+		data, err = EncodeType(strings.Replace(typeString, "compact", "Compact", 1), value)
+		if err == nil {
+			bytes, err = NewBytes(data)
+		}
 	case "string":
 		bytes, err = StringToBytes(value)
 	default:
@@ -34,10 +36,6 @@ func Encode(typeString string, value interface{}) (res string, err error) {
 		return
 	}
 
-	if strings.HasPrefix(typeString, "compact") {
-		bytes, err = bytes.ToCompact()
-	}
-
 	if err == nil {
 		res = bytes.ToHex()
 	}
@@ -0,0 +1,55 @@
+package codec
+
+import (
+	"testing"
+)
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		typeString string
+		value      interface{}
+		expect     string
+	}{
+		{"bool", true, "01"},
+		{"bool", false, "00"},
+		//整数为固定长度的小端字节，不去掉高位的0
+		{"u8", 1, "01"},
+		{"u16", 1, "0100"},
+		{"u32", 1, "01000000"},
+		{"u64", uint64(1), "0100000000000000"},
+		{"U64", 0, "0000000000000000"},
+		{"u128", "1000000000000000000", "000064a7b3b6e00d0000000000000000"},
+		{"compact<u32>", 1, "04"},
+		{"compact<u32>", 64, "0101"},
+		{"Compact<u64>", 16384, "02000100"},
+		{"compact<u128>", "1000000000000000000", "13000064a7b3b6e00d"},
+		//string不带长度前缀
+		{"string", "PCX", "504358"},
+	}
+	for _, test := range tests {
+		res, err := Encode(test.typeString, test.value)
+		if err != nil {
+			t.Errorf("%s %v: %v", test.typeString, test.value, err)
+			continue
+		}
+		if res != test.expect {
+			t.Errorf("%s %v is %s,expect %s", test.typeString, test.value, res, test.expect)
+		}
+	}
+
+	for _, test := range []struct {
+		typeString string
+		value      interface{}
+	}{
+		{"u8", 256},
+		{"u32", -1},
+		{"compact<u32>", -1},
+		{"bool", 1},
+		{"string", 1},
+		{"vec<u8>", "0x01"},
+	} {
+		if _, err := Encode(test.typeString, test.value); err == nil {
+			t.Errorf("%s %v: expect error", test.typeString, test.value)
+		}
+	}
+}
@@ -0,0 +1,419 @@
+package codec
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/JFJun/chainX-go/ss58"
+)
+
+//NamedValue 按类型解析出的一个参数
+type NamedValue struct {
+	Name  string      `json:"name"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+/*
+TypeDecoder 根据metadata中的类型字符串解析SCALE编码的数据
+基础类型直接解析，其他类型通过RegisterAlias，RegisterStruct，RegisterEnum注册
+解析结果：
+	u8-u64，Compact -> uint64 (超过uint64时为*big.Int)
+	i8-i64 -> int64，u128/i128 -> *big.Int
+	Vec<u8>，[u8;n] -> 0x开头的hex，Text -> string
+	AccountId，Address -> ss58地址，账户索引为uint64
+	Vec，tuple，数组 -> []interface{}，BTreeMap和Vec<(K,V)>相同
+	struct -> []NamedValue，有数据的枚举 -> NamedValue，只有名字的枚举 -> string
+*/
+type TypeDecoder struct {
+	data   []byte
+	offset int
+	//解析Call类型时使用，例如sudo和多签交易中嵌套的交易
+	CallDecoder func(d *TypeDecoder) (interface{}, error)
+}
+
+func NewTypeDecoder(data []byte) *TypeDecoder {
+	return &TypeDecoder{data: data}
+}
+
+//已经解析的字节数
+func (d *TypeDecoder) Offset() int {
+	return d.offset
+}
+
+//剩余没有解析的字节数
+func (d *TypeDecoder) Remaining() int {
+	return len(d.data) - d.offset
+}
+
+//读取length个字节，剩余的字节不够时返回错误
+func (d *TypeDecoder) NextBytes(length int) ([]byte, error) {
+	if length < 0 || d.offset+length > len(d.data) {
+		return nil, fmt.Errorf("out of range,need %d bytes but remaining %d", length, d.Remaining())
+	}
+	data := d.data[d.offset : d.offset+length]
+	d.offset += length
+	return data, nil
+}
+
+//返回下一个字节，不移动offset
+func (d *TypeDecoder) PeekByte() (byte, error) {
+	if d.Remaining() < 1 {
+		return 0, fmt.Errorf("out of range,need 1 bytes but remaining %d", d.Remaining())
+	}
+	return d.data[d.offset], nil
+}
+
+func (d *TypeDecoder) NextByte() (byte, error) {
+	b, err := d.NextBytes(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+//解析compact编码的整数
+func (d *TypeDecoder) DecodeCompact() (*big.Int, error) {
+	b, err := d.NextByte()
+	if err != nil {
+		return nil, err
+	}
+	switch b & 3 {
+	case 0:
+		return big.NewInt(int64(b >> 2)), nil
+	case 1:
+		next, err := d.NextByte()
+		if err != nil {
+			return nil, err
+		}
+		return big.NewInt(int64(binary.LittleEndian.Uint16([]byte{b, next}) >> 2)), nil
+	case 2:
+		next, err := d.NextBytes(3)
+		if err != nil {
+			return nil, err
+		}
+		v := binary.LittleEndian.Uint32(append([]byte{b}, next...)) >> 2
+		return big.NewInt(int64(v)), nil
+	default:
+		data, err := d.NextBytes(int(b>>2) + 4)
+		if err != nil {
+			return nil, err
+		}
+		return new(big.Int).SetBytes(RevertBytes(data)), nil
+	}
+}
+
+//解析长度前缀，例如Vec和Text的长度
+func (d *TypeDecoder) DecodeLength() (int, error) {
+	l, err := d.DecodeCompact()
+	if err != nil {
+		return 0, err
+	}
+	if !l.IsInt64() || l.Int64() > int64(d.Remaining()) {
+		return 0, fmt.Errorf("invalid length %s", l.String())
+	}
+	return int(l.Int64()), nil
+}
+
+//按类型字符串解析一个值
+func (d *TypeDecoder) Decode(typeString string) (interface{}, error) {
+	t := NormalizeType(typeString)
+	v, err := d.decodeType(t)
+	if err != nil {
+		return nil, fmt.Errorf("decode type %s error,err=%v", t, err)
+	}
+	return v, nil
+}
+
+func (d *TypeDecoder) decodeType(t string) (interface{}, error) {
+	switch t {
+	case "Null", "()":
+		return nil, nil
+	case "bool":
+		b, err := d.NextByte()
+		if err != nil {
+			return nil, err
+		}
+		if b > 1 {
+			return nil, fmt.Errorf("invalid bool value %d", b)
+		}
+		return b == 1, nil
+	case "u8", "u16", "u32", "u64":
+		size, _ := strconv.Atoi(t[1:])
+		data, err := d.NextBytes(size / 8)
+		if err != nil {
+			return nil, err
+		}
+		return new(big.Int).SetBytes(RevertBytes(data)).Uint64(), nil
+	case "i8", "i16", "i32", "i64":
+		size, _ := strconv.Atoi(t[1:])
+		data, err := d.NextBytes(size / 8)
+		if err != nil {
+			return nil, err
+		}
+		v := int64(binary.LittleEndian.Uint64(ExtendLEBytes(append([]byte{}, data...), 8)))
+		shift := uint(64 - size)
+		return v << shift >> shift, nil
+	case "u128", "i128":
+		data, err := d.NextBytes(16)
+		if err != nil {
+			return nil, err
+		}
+		v := new(big.Int).SetBytes(RevertBytes(data))
+		if t == "i128" && data[15]&0x80 != 0 {
+			v.Sub(v, new(big.Int).Lsh(big.NewInt(1), 128))
+		}
+		return v, nil
+	case "Text", "String", "Str", "&str", "Vec<u8>", "Bytes":
+		l, err := d.DecodeLength()
+		if err != nil {
+			return nil, err
+		}
+		data, err := d.NextBytes(l)
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(t, "Vec") || t == "Bytes" {
+			return "0x" + hex.EncodeToString(data), nil
+		}
+		return string(data), nil
+	case "AccountId":
+		data, err := d.NextBytes(32)
+		if err != nil {
+			return nil, err
+		}
+		return ss58.Encode(data, ss58.ChainXPrefix)
+	case "Address", "LookupSource":
+		return d.decodeAddress()
+	case "Call":
+		if d.CallDecoder == nil {
+			return nil, fmt.Errorf("call decoder is not set")
+		}
+		return d.CallDecoder(d)
+	}
+
+	if _, ok := genericInner(t, "Compact"); ok {
+		v, err := d.DecodeCompact()
+		if err != nil {
+			return nil, err
+		}
+		if v.IsUint64() {
+			return v.Uint64(), nil
+		}
+		return v, nil
+	}
+	if inner, ok := genericInner(t, "Box"); ok {
+		return d.decodeType(inner)
+	}
+	if inner, ok := genericInner(t, "Option"); ok {
+		return d.decodeOption(inner)
+	}
+	//BTreeMap的编码和Vec<(K,V)>相同
+	for _, name := range []string{"BTreeMap", "CodecBTreeMap"} {
+		if inner, ok := genericInner(t, name); ok {
+			return d.decodeType("Vec<(" + inner + ")>")
+		}
+	}
+	if inner, ok := genericInner(t, "Vec"); ok {
+		l, err := d.DecodeLength()
+		if err != nil {
+			return nil, err
+		}
+		values := make([]interface{}, 0, l)
+		for i := 0; i < l; i++ {
+			v, err := d.decodeType(inner)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		return values, nil
+	}
+	if strings.HasPrefix(t, "(") && strings.HasSuffix(t, ")") {
+		var values []interface{}
+		for _, sub := range splitTypes(t[1 : len(t)-1]) {
+			v, err := d.decodeType(sub)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		return values, nil
+	}
+	if strings.HasPrefix(t, "[") && strings.HasSuffix(t, "]") {
+		return d.decodeArray(t)
+	}
+
+	def, ok := lookupType(t)
+	if !ok {
+		return nil, fmt.Errorf("unknown type %s", t)
+	}
+	switch def.kind {
+	case typeAlias:
+		return d.decodeType(NormalizeType(def.alias))
+	case typeStruct:
+		values := make([]NamedValue, 0, len(def.fields))
+		for _, f := range def.fields {
+			v, err := d.decodeType(NormalizeType(f.Type))
+			if err != nil {
+				return nil, fmt.Errorf("field %s error,err=%v", f.Name, err)
+			}
+			values = append(values, NamedValue{Name: f.Name, Type: f.Type, Value: v})
+		}
+		return values, nil
+	default:
+		index, err := d.NextByte()
+		if err != nil {
+			return nil, err
+		}
+		if int(index) >= len(def.fields) {
+			return nil, fmt.Errorf("invalid enum index %d", index)
+		}
+		variant := def.fields[index]
+		if variant.Type == "" || variant.Type == "Null" {
+			return variant.Name, nil
+		}
+		v, err := d.decodeType(NormalizeType(variant.Type))
+		if err != nil {
+			return nil, err
+		}
+		return NamedValue{Name: variant.Name, Type: variant.Type, Value: v}, nil
+	}
+}
+
+//Option<bool>只使用一个字节，0为None，1为true，2为false
+func (d *TypeDecoder) decodeOption(inner string) (interface{}, error) {
+	flag, err := d.NextByte()
+	if err != nil {
+		return nil, err
+	}
+	if inner == "bool" {
+		switch flag {
+		case 0:
+			return nil, nil
+		case 1:
+			return true, nil
+		case 2:
+			return false, nil
+		}
+		return nil, fmt.Errorf("invalid option bool flag %d", flag)
+	}
+	switch flag {
+	case 0:
+		return nil, nil
+	case 1:
+		return d.decodeType(inner)
+	}
+	return nil, fmt.Errorf("invalid option flag %d", flag)
+}
+
+//[u8;32]解析为hex，其他类型的数组解析为[]interface{}
+func (d *TypeDecoder) decodeArray(t string) (interface{}, error) {
+	parts := strings.Split(t[1:len(t)-1], ";")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid array type %s", t)
+	}
+	n, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid array length %s", parts[1])
+	}
+	if parts[0] == "u8" {
+		data, err := d.NextBytes(n)
+		if err != nil {
+			return nil, err
+		}
+		return "0x" + hex.EncodeToString(data), nil
+	}
+	values := make([]interface{}, 0, n)
+	for i := 0; i < n; i++ {
+		v, err := d.decodeType(parts[0])
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+/*
+Address的第一个字节表示类型：
+	0xff 后面是32字节的AccountId
+	0xfe，0xfd，0xfc 后面是8，4，2字节的账户索引，索引必须大于更短编码能表示的最大值
+	0x00-0xef 本身就是账户索引
+*/
+func (d *TypeDecoder) decodeAddress() (interface{}, error) {
+	b, err := d.NextByte()
+	if err != nil {
+		return nil, err
+	}
+	var (
+		size int
+		min  uint64
+	)
+	switch b {
+	case 0xff:
+		data, err := d.NextBytes(32)
+		if err != nil {
+			return nil, err
+		}
+		return ss58.Encode(data, ss58.ChainXPrefix)
+	case 0xfe:
+		size, min = 8, 0xffffffff
+	case 0xfd:
+		size, min = 4, 0xffff
+	case 0xfc:
+		size, min = 2, 0xef
+	default:
+		if b > 0xef {
+			return nil, fmt.Errorf("invalid address type %x", b)
+		}
+		return uint64(b), nil
+	}
+	data, err := d.NextBytes(size)
+	if err != nil {
+		return nil, err
+	}
+	index := binary.LittleEndian.Uint64(ExtendLEBytes(append([]byte{}, data...), 8))
+	if index <= min {
+		return nil, fmt.Errorf("account index %d is not canonical encoded", index)
+	}
+	return index, nil
+}
+
+//Vec<u32>返回u32，不是Vec开头时返回false
+func genericInner(t, name string) (string, bool) {
+	if !strings.HasPrefix(t, name+"<") || !strings.HasSuffix(t, ">") {
+		return "", false
+	}
+	return t[len(name)+1 : len(t)-1], true
+}
+
+//按最外层的逗号分割tuple中的类型
+func splitTypes(t string) []string {
+	var (
+		result []string
+		depth  int
+		start  int
+	)
+	for i, c := range t {
+		switch c {
+		case '<', '(', '[':
+			depth++
+		case '>', ')', ']':
+			depth--
+		case ',':
+			if depth == 0 {
+				result = append(result, t[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if start < len(t) {
+		result = append(result, t[start:])
+	}
+	return result
+}
@@ -0,0 +1,181 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/JFJun/chainX-go/ss58"
+)
+
+func mustBigInt(t *testing.T, s string) *big.Int {
+	v, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		t.Fatalf("invalid integer %s", s)
+	}
+	return v
+}
+
+func TestCompactBigBytes(t *testing.T) {
+	tests := []struct {
+		value  string
+		expect string
+	}{
+		{"0", "00"},
+		{"63", "fc"},
+		{"64", "0101"},
+		{"16383", "fdff"},
+		{"16384", "02000100"},
+		{"1073741823", "feffffff"},
+		{"1073741824", "0300000040"},
+		{"18446744073709551615", "13ffffffffffffffff"},
+		{"340282366920938463463374607431768211455", "33ffffffffffffffffffffffffffffffff"},
+	}
+	for _, test := range tests {
+		v := mustBigInt(t, test.value)
+		data := CompactBigBytes(v)
+		if hex.EncodeToString(data) != test.expect {
+			t.Errorf("compact %s is %x,expect %s", test.value, data, test.expect)
+			continue
+		}
+		if v.IsUint64() && !bytes.Equal(CompactBytes(v.Uint64()), data) {
+			t.Errorf("CompactBytes(%s) is %x", test.value, CompactBytes(v.Uint64()))
+		}
+		d := NewTypeDecoder(data)
+		res, err := d.DecodeCompact()
+		if err != nil {
+			t.Errorf("decode compact %s: %v", test.value, err)
+			continue
+		}
+		if res.Cmp(v) != 0 || d.Remaining() != 0 {
+			t.Errorf("decode compact %s is %s,remaining %d", test.value, res.String(), d.Remaining())
+		}
+	}
+}
+
+func TestTypeRoundTrip(t *testing.T) {
+	pub := bytes.Repeat([]byte{0x01}, 32)
+	account, _ := ss58.Encode(pub, ss58.ChainXPrefix)
+	tests := []struct {
+		typeString string
+		value      interface{}
+		expect     string
+		//解析结果和value不同时使用
+		decoded interface{}
+	}{
+		{"bool", true, "01", nil},
+		{"u8", uint64(255), "ff", nil},
+		{"u16", uint64(513), "0102", nil},
+		{"u32", uint64(1), "01000000", nil},
+		{"u64", uint64(1), "0100000000000000", nil},
+		{"u128", mustBigInt(t, "1000000000000000000"), "000064a7b3b6e00d0000000000000000", nil},
+		{"i8", int64(-1), "ff", nil},
+		{"i32", int64(-2), "feffffff", nil},
+		{"i128", big.NewInt(-1), "ffffffffffffffffffffffffffffffff", nil},
+		{"Compact<u32>", uint64(64), "0101", nil},
+		{"Compact<Balance>", uint64(1073741824), "0300000040", nil},
+		{"Compact<u128>", mustBigInt(t, "340282366920938463463374607431768211455"), "33ffffffffffffffffffffffffffffffff", nil},
+		{"Balance", uint64(100), "6400000000000000", nil},
+		{"Text", "PCX", "0c504358", nil},
+		{"Token", "PCX", "0c504358", nil},
+		{"Vec<u8>", "0x0102", "080102", nil},
+		{"[u8;4]", "0x01020304", "01020304", nil},
+		{"AccountId", account, "0101010101010101010101010101010101010101010101010101010101010101", nil},
+		{"Vec<u32>", []interface{}{uint64(1), uint64(2)}, "080100000002000000", nil},
+		{"(u8,Text)", []interface{}{uint64(1), "a"}, "010461", nil},
+		{"BTreeMap<Token,Balance>", []interface{}{[]interface{}{"PCX", uint64(1)}}, "040c5043580100000000000000", nil},
+		{"Option<bool>", nil, "00", nil},
+		{"Option<bool>", true, "01", nil},
+		{"Option<bool>", false, "02", nil},
+		{"Option<u32>", uint64(1), "0101000000", nil},
+		{"AssetType", "ReservedDexSpot", "04", nil},
+		{"Vec<AssetType>", []interface{}{"Free", "GasPayment"}, "080008", nil},
+		{"TrusteeEntity", NamedValue{Name: "Bitcoin", Type: "Vec<u8>", Value: "0x01"}, "000401", nil},
+		{"Asset", []NamedValue{
+			{Name: "token", Type: "Token", Value: "PCX"},
+			{Name: "token_name", Type: "Token", Value: "PCX"},
+			{Name: "chain", Type: "Chain", Value: "ChainX"},
+			{Name: "precision", Type: "Precision", Value: uint64(8)},
+			{Name: "desc", Type: "Desc", Value: "a"},
+		}, "0c5043580c50435800080004" + "61", nil},
+		//Address: 0xff加上32字节的公钥，整数使用最短的账户索引编码
+		{"Address", account, "ff0101010101010101010101010101010101010101010101010101010101010101", nil},
+		{"Address", "0x0101010101010101010101010101010101010101010101010101010101010101", "ff0101010101010101010101010101010101010101010101010101010101010101", account},
+		{"Address", uint64(0xef), "ef", nil},
+		{"Address", uint64(0xf0), "fcf000", nil},
+		{"Address", uint64(0x10000), "fd00000100", nil},
+		{"Address", uint64(0x100000000), "fe0000000001000000", nil},
+	}
+	for _, test := range tests {
+		data, err := EncodeType(test.typeString, test.value)
+		if err != nil {
+			t.Errorf("encode %s: %v", test.typeString, err)
+			continue
+		}
+		if hex.EncodeToString(data) != test.expect {
+			t.Errorf("encode %s is %x,expect %s", test.typeString, data, test.expect)
+			continue
+		}
+		d := NewTypeDecoder(data)
+		res, err := d.Decode(test.typeString)
+		if err != nil {
+			t.Errorf("decode %s: %v", test.typeString, err)
+			continue
+		}
+		expect := test.value
+		if test.decoded != nil {
+			expect = test.decoded
+		}
+		if !reflect.DeepEqual(res, expect) {
+			t.Errorf("decode %s is %#v,expect %#v", test.typeString, res, expect)
+		}
+		if d.Remaining() != 0 {
+			t.Errorf("decode %s remaining %d bytes", test.typeString, d.Remaining())
+		}
+	}
+}
+
+func TestDecodeAddressInvalid(t *testing.T) {
+	for _, data := range []string{
+		//账户索引没有使用最短的编码
+		"fc0100",
+		"fcef00",
+		"fdffff0000",
+		"feffffffff00000000",
+		//0xf0到0xfb不是合法的类型
+		"f0",
+		"fb",
+		//长度不够
+		"ff0101",
+		"fd0100",
+		"",
+	} {
+		b, _ := hex.DecodeString(data)
+		if _, err := NewTypeDecoder(b).Decode("Address"); err == nil {
+			t.Errorf("address %s: expect error", data)
+		}
+	}
+}
+
+func TestTypeDecoderInvalid(t *testing.T) {
+	tests := []struct {
+		typeString string
+		data       string
+	}{
+		{"bool", "02"},
+		{"u32", "010000"},
+		{"Option<bool>", "03"},
+		{"Option<u32>", "02"},
+		{"Text", "08"},
+		{"AssetType", "09"},
+		{"UnknownType", "00"},
+	}
+	for _, test := range tests {
+		b, _ := hex.DecodeString(test.data)
+		if _, err := NewTypeDecoder(b).Decode(test.typeString); err == nil {
+			t.Errorf("decode %s %s: expect error", test.typeString, test.data)
+		}
+	}
+}
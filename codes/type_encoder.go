@@ -0,0 +1,412 @@
+package codec
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/JFJun/chainX-go/ss58"
+)
+
+//ScaleEncoder 自己实现编码的类型，例如tx.Call，编码时直接使用返回的数据
+type ScaleEncoder interface {
+	ScaleBytes() ([]byte, error)
+}
+
+//compact编码，0-63使用1个字节，64-16383使用2个字节，16384-2^30-1使用4个字节，其他使用大整数模式
+func CompactBytes(v uint64) []byte {
+	return CompactBigBytes(new(big.Int).SetUint64(v))
+}
+
+func CompactBigBytes(v *big.Int) []byte {
+	switch {
+	case v.Cmp(big.NewInt(singleModeMaxValue)) <= 0:
+		return []byte{byte(v.Uint64() << 2)}
+	case v.Cmp(big.NewInt(twoByteModeMaxValue)) <= 0:
+		buf := make([]byte, 2)
+		binary.LittleEndian.PutUint16(buf, uint16(v.Uint64()<<2|uint64(twoByteMode)))
+		return buf
+	case v.Cmp(big.NewInt(fourByteModeMaxValue)) <= 0:
+		buf := make([]byte, 4)
+		binary.LittleEndian.PutUint32(buf, uint32(v.Uint64()<<2|uint64(fourByteMode)))
+		return buf
+	default:
+		data := RevertBytes(v.Bytes())
+		return append([]byte{byte(len(data)-4)<<2 | bigIntMode}, data...)
+	}
+}
+
+//按类型字符串编码一个值，类型和TypeDecoder使用的相同
+func EncodeType(typeString string, value interface{}) ([]byte, error) {
+	t := NormalizeType(typeString)
+	data, err := encodeType(t, value)
+	if err != nil {
+		return nil, fmt.Errorf("encode type %s error,err=%v", t, err)
+	}
+	return data, nil
+}
+
+func encodeType(t string, value interface{}) ([]byte, error) {
+	if enc, ok := value.(ScaleEncoder); ok {
+		return enc.ScaleBytes()
+	}
+	switch t {
+	case "Null", "()":
+		return nil, nil
+	case "bool":
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("bool value type is %T", value)
+		}
+		if b {
+			return []byte{1}, nil
+		}
+		return []byte{0}, nil
+	case "u8", "u16", "u32", "u64", "u128", "i8", "i16", "i32", "i64", "i128":
+		return encodeInt(t, value)
+	case "Text", "String", "Str", "&str":
+		var data []byte
+		switch v := value.(type) {
+		case string:
+			data = []byte(v)
+		case []byte:
+			data = v
+		default:
+			return nil, fmt.Errorf("text value type is %T", value)
+		}
+		return append(CompactBytes(uint64(len(data))), data...), nil
+	case "Vec<u8>", "Bytes":
+		data, err := toBytes(value, -1)
+		if err != nil {
+			return nil, err
+		}
+		return append(CompactBytes(uint64(len(data))), data...), nil
+	case "AccountId":
+		return toPublicKey(value)
+	case "Address", "LookupSource":
+		return encodeAddress(value)
+	}
+
+	if _, ok := genericInner(t, "Compact"); ok {
+		v, err := toBigInt(value)
+		if err != nil {
+			return nil, err
+		}
+		if v.Sign() < 0 {
+			return nil, errors.New("compact value is negative")
+		}
+		return CompactBigBytes(v), nil
+	}
+	if inner, ok := genericInner(t, "Box"); ok {
+		return encodeType(inner, value)
+	}
+	if inner, ok := genericInner(t, "Option"); ok {
+		if isNil(value) {
+			return []byte{0}, nil
+		}
+		if inner == "bool" {
+			b, ok := value.(bool)
+			if !ok {
+				return nil, fmt.Errorf("bool value type is %T", value)
+			}
+			if b {
+				return []byte{1}, nil
+			}
+			return []byte{2}, nil
+		}
+		data, err := encodeType(inner, value)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte{1}, data...), nil
+	}
+	//BTreeMap的编码和Vec<(K,V)>相同
+	for _, name := range []string{"BTreeMap", "CodecBTreeMap"} {
+		if inner, ok := genericInner(t, name); ok {
+			return encodeType("Vec<("+inner+")>", value)
+		}
+	}
+	if inner, ok := genericInner(t, "Vec"); ok {
+		items, err := toSlice(value)
+		if err != nil {
+			return nil, err
+		}
+		data := CompactBytes(uint64(len(items)))
+		for _, item := range items {
+			d, err := encodeType(inner, item)
+			if err != nil {
+				return nil, err
+			}
+			data = append(data, d...)
+		}
+		return data, nil
+	}
+	if strings.HasPrefix(t, "(") && strings.HasSuffix(t, ")") {
+		types := splitTypes(t[1 : len(t)-1])
+		items, err := toSlice(value)
+		if err != nil {
+			return nil, err
+		}
+		if len(items) != len(types) {
+			return nil, fmt.Errorf("tuple need %d values but got %d", len(types), len(items))
+		}
+		var data []byte
+		for i, sub := range types {
+			d, err := encodeType(sub, items[i])
+			if err != nil {
+				return nil, err
+			}
+			data = append(data, d...)
+		}
+		return data, nil
+	}
+	if strings.HasPrefix(t, "[") && strings.HasSuffix(t, "]") {
+		return encodeArray(t, value)
+	}
+
+	def, ok := lookupType(t)
+	if !ok {
+		return nil, fmt.Errorf("unknown type %s", t)
+	}
+	switch def.kind {
+	case typeAlias:
+		return encodeType(NormalizeType(def.alias), value)
+	case typeStruct:
+		return encodeStruct(def, value)
+	default:
+		return encodeEnum(def, value)
+	}
+}
+
+//struct的值可以是[]NamedValue或者map[string]interface{}
+func encodeStruct(def *typeDef, value interface{}) ([]byte, error) {
+	fields := make(map[string]interface{})
+	switch v := value.(type) {
+	case []NamedValue:
+		for _, f := range v {
+			fields[f.Name] = f.Value
+		}
+	case map[string]interface{}:
+		fields = v
+	default:
+		return nil, fmt.Errorf("struct value type is %T", value)
+	}
+	var data []byte
+	for _, f := range def.fields {
+		fv, ok := fields[f.Name]
+		if !ok {
+			return nil, fmt.Errorf("struct field %s is missing", f.Name)
+		}
+		d, err := encodeType(NormalizeType(f.Type), fv)
+		if err != nil {
+			return nil, fmt.Errorf("field %s error,err=%v", f.Name, err)
+		}
+		data = append(data, d...)
+	}
+	return data, nil
+}
+
+//只有名字的枚举使用string，有数据的枚举使用NamedValue
+func encodeEnum(def *typeDef, value interface{}) ([]byte, error) {
+	var (
+		name string
+		v    interface{}
+	)
+	switch ev := value.(type) {
+	case string:
+		name = ev
+	case NamedValue:
+		name, v = ev.Name, ev.Value
+	case *NamedValue:
+		name, v = ev.Name, ev.Value
+	default:
+		return nil, fmt.Errorf("enum value type is %T", value)
+	}
+	for i, variant := range def.fields {
+		if !strings.EqualFold(variant.Name, name) {
+			continue
+		}
+		data := []byte{byte(i)}
+		if variant.Type == "" || variant.Type == "Null" {
+			return data, nil
+		}
+		d, err := encodeType(NormalizeType(variant.Type), v)
+		if err != nil {
+			return nil, err
+		}
+		return append(data, d...), nil
+	}
+	return nil, fmt.Errorf("unknown enum variant %s", name)
+}
+
+func encodeArray(t string, value interface{}) ([]byte, error) {
+	parts := strings.Split(t[1:len(t)-1], ";")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid array type %s", t)
+	}
+	n, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid array length %s", parts[1])
+	}
+	if parts[0] == "u8" {
+		return toBytes(value, n)
+	}
+	items, err := toSlice(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) != n {
+		return nil, fmt.Errorf("array need %d values but got %d", n, len(items))
+	}
+	var data []byte
+	for _, item := range items {
+		d, err := encodeType(parts[0], item)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, d...)
+	}
+	return data, nil
+}
+
+//固定长度的整数，使用小端字节
+func encodeInt(t string, value interface{}) ([]byte, error) {
+	v, err := toBigInt(value)
+	if err != nil {
+		return nil, err
+	}
+	size, _ := strconv.Atoi(t[1:])
+	size = size / 8
+	if t[0] == 'i' && v.Sign() < 0 {
+		v = new(big.Int).Add(v, new(big.Int).Lsh(big.NewInt(1), uint(size*8)))
+	} else if v.Sign() < 0 {
+		return nil, fmt.Errorf("value %s is negative", v.String())
+	}
+	if v.BitLen() > size*8 {
+		return nil, fmt.Errorf("value %s overflow %s", v.String(), t)
+	}
+	return ExtendLEBytes(RevertBytes(v.Bytes()), size), nil
+}
+
+//地址的编码规则和TypeDecoder.decodeAddress相同，整数作为账户索引
+func encodeAddress(value interface{}) ([]byte, error) {
+	switch value.(type) {
+	case string, []byte:
+		pub, err := toPublicKey(value)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte{0xff}, pub...), nil
+	}
+	v, err := toBigInt(value)
+	if err != nil {
+		return nil, err
+	}
+	if !v.IsUint64() {
+		return nil, fmt.Errorf("invalid account index %s", v.String())
+	}
+	index := v.Uint64()
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(buf, index)
+	switch {
+	case index <= 0xef:
+		return []byte{byte(index)}, nil
+	case index <= 0xffff:
+		return append([]byte{0xfc}, buf[:2]...), nil
+	case index <= 0xffffffff:
+		return append([]byte{0xfd}, buf[:4]...), nil
+	default:
+		return append([]byte{0xfe}, buf...), nil
+	}
+}
+
+//公钥可以是ss58地址，0x开头的hex或者32字节的[]byte
+func toPublicKey(value interface{}) ([]byte, error) {
+	if s, ok := value.(string); ok && !strings.HasPrefix(s, "0x") {
+		pub, err := ss58.DecodeToPub(s)
+		if err != nil {
+			return nil, fmt.Errorf("decode address %s error,err=%v", s, err)
+		}
+		if len(pub) != 32 {
+			return nil, fmt.Errorf("invalid address %s", s)
+		}
+		return pub, nil
+	}
+	return toBytes(value, 32)
+}
+
+//字节可以是[]byte或者0x开头的hex，length小于0时不检查长度
+func toBytes(value interface{}, length int) ([]byte, error) {
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		if !strings.HasPrefix(v, "0x") {
+			return nil, fmt.Errorf("bytes string %s must start with 0x", v)
+		}
+		var err error
+		data, err = hex.DecodeString(v[2:])
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("bytes value type is %T", value)
+	}
+	if length >= 0 && len(data) != length {
+		return nil, fmt.Errorf("need %d bytes but got %d", length, len(data))
+	}
+	return data, nil
+}
+
+func toBigInt(value interface{}) (*big.Int, error) {
+	switch v := value.(type) {
+	case *big.Int:
+		return v, nil
+	case big.Int:
+		return &v, nil
+	case string:
+		i, ok := new(big.Int).SetString(v, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid integer %s", v)
+		}
+		return i, nil
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return big.NewInt(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return new(big.Int).SetUint64(rv.Uint()), nil
+	}
+	return nil, fmt.Errorf("integer value type is %T", value)
+}
+
+func toSlice(value interface{}) ([]interface{}, error) {
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, fmt.Errorf("value type %T is not slice", value)
+	}
+	items := make([]interface{}, rv.Len())
+	for i := range items {
+		items[i] = rv.Index(i).Interface()
+	}
+	return items, nil
+}
+
+func isNil(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package codec
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+//NamedType 结构体的字段或者枚举的成员，枚举成员没有数据时Type为空
+type NamedType struct {
+	Name string
+	Type string
+}
+
+const (
+	typeAlias  = 1
+	typeStruct = 2
+	typeEnum   = 3
+)
+
+type typeDef struct {
+	kind   int
+	alias  string
+	fields []NamedType
+}
+
+var (
+	typeLock sync.RWMutex
+	typeDefs = make(map[string]*typeDef)
+)
+
+//注册类型别名，例如RegisterAlias("Balance","u64")
+func RegisterAlias(name, typeString string) {
+	registerType(name, &typeDef{kind: typeAlias, alias: typeString})
+}
+
+//注册结构体类型，字段按顺序解析
+func RegisterStruct(name string, fields []NamedType) {
+	registerType(name, &typeDef{kind: typeStruct, fields: fields})
+}
+
+//注册枚举类型，第一个字节为成员的序号
+func RegisterEnum(name string, variants []NamedType) {
+	registerType(name, &typeDef{kind: typeEnum, fields: variants})
+}
+
+//只有名字的枚举
+func RegisterSimpleEnum(name string, variants ...string) {
+	fields := make([]NamedType, len(variants))
+	for i, v := range variants {
+		fields[i].Name = v
+	}
+	RegisterEnum(name, fields)
+}
+
+func registerType(name string, def *typeDef) {
+	typeLock.Lock()
+	defer typeLock.Unlock()
+	typeDefs[NormalizeType(name)] = def
+}
+
+func lookupType(name string) (*typeDef, bool) {
+	typeLock.RLock()
+	defer typeLock.RUnlock()
+	def, ok := typeDefs[name]
+	return def, ok
+}
+
+var (
+	traitPrefix = regexp.MustCompile(`<T as [A-Za-z_:]+(<I>)?>::`)
+	tPrefix     = regexp.MustCompile(`\bT::`)
+	whitespace  = regexp.MustCompile(`\s+`)
+)
+
+/*
+去掉metadata类型字符串中的泛型前缀和空格
+例如 "<T as Trait>::Balance" -> "Balance"，"Vec<T::AccountId>" -> "Vec<AccountId>"
+*/
+func NormalizeType(t string) string {
+	t = strings.Replace(t, "<T::Lookup as StaticLookup>::Source", "Address", -1)
+	t = traitPrefix.ReplaceAllString(t, "")
+	t = tPrefix.ReplaceAllString(t, "")
+	t = whitespace.ReplaceAllString(t, "")
+	return t
+}
+
+//ChainX 1.0运行时中使用的类型
+func init() {
+	for _, name := range []string{"Balance", "BalanceOf", "BlockNumber", "Moment", "Index", "Price", "OrderIndex", "ID", "Amount", "Weight"} {
+		RegisterAlias(name, "u64")
+	}
+	for _, name := range []string{"AccountIndex", "TradingPairIndex", "SessionIndex", "Permill", "Perbill", "PropIndex", "ReferendumIndex", "Nonce"} {
+		RegisterAlias(name, "u32")
+	}
+	for _, name := range []string{"Token", "Memo", "XString", "AddrStr", "Name", "URL", "Desc"} {
+		RegisterAlias(name, "Text")
+	}
+	for _, name := range []string{"Hash", "BlockHash", "AuthorityId", "SessionKey", "H256", "ValidatorId"} {
+		RegisterAlias(name, "[u8;32]")
+	}
+	RegisterAlias("Precision", "u16")
+	RegisterAlias("H160", "[u8;20]")
+	RegisterAlias("H512", "[u8;64]")
+	RegisterAlias("Signature", "[u8;64]")
+	RegisterAlias("Proposal", "Call")
+	RegisterSimpleEnum("Chain", "ChainX", "Bitcoin", "Ethereum", "Polkadot")
+	RegisterSimpleEnum("OrderType", "Limit", "Market")
+	RegisterSimpleEnum("Side", "Buy", "Sell")
+	RegisterSimpleEnum("AssetType", "Free", "ReservedStaking", "ReservedStakingRevocation", "ReservedWithdrawal", "ReservedDexSpot", "ReservedDexFuture", "ReservedCurrency", "ReservedXRC20", "GasPayment")
+	RegisterSimpleEnum("OrderStatus", "ZeroFill", "ParitialFill", "Filled", "ParitialFillAndCanceled", "Canceled")
+	RegisterSimpleEnum("TxType", "Withdrawal", "Deposit", "HotAndCold", "TrusteeTransition", "Lock", "Unlock", "Irrelevance")
+	RegisterEnum("TrusteeEntity", []NamedType{{Name: "Bitcoin", Type: "Vec<u8>"}})
+	RegisterStruct("Asset", []NamedType{
+		{Name: "token", Type: "Token"},
+		{Name: "token_name", Type: "Token"},
+		{Name: "chain", Type: "Chain"},
+		{Name: "precision", Type: "Precision"},
+		{Name: "desc", Type: "Desc"},
+	})
+}
@@ -0,0 +1,126 @@
+package events
+
+import (
+	"encoding/hex"
+	"fmt"
+	codec "github.com/JFJun/chainX-go/codes"
+	"github.com/JFJun/chainX-go/metadata"
+	"github.com/JFJun/chainX-go/util"
+)
+
+const (
+	PhaseApplyExtrinsic = "ApplyExtrinsic"
+	PhaseFinalization   = "Finalization"
+	PhaseInitialization = "Initialization"
+)
+
+//EventRecord System Events中的一个事件
+type EventRecord struct {
+	Phase          string             `json:"phase"`
+	ExtrinsicIndex int                `json:"extrinsic_index"` //Phase不是ApplyExtrinsic时为-1
+	Module         string             `json:"module"`
+	Event          string             `json:"event"`
+	EventIndex     string             `json:"event_index"`
+	Args           []codec.NamedValue `json:"args"`
+	Topics         []string           `json:"topics"`
+}
+
+//解析state_getStorage返回的System Events
+func DecodeEventRecordsHex(meta *metadata.Metadata, eventsHex string) ([]*EventRecord, error) {
+	data, err := hex.DecodeString(util.RemoveHex0x(eventsHex))
+	if err != nil {
+		return nil, fmt.Errorf("decode events hex error,err=%v", err)
+	}
+	return DecodeEventRecords(meta, data)
+}
+
+/*
+System Events的值为Vec<EventRecord>，EventRecord为 phase + event + topics
+是否有topics由metadata决定，见hasTopics
+*/
+func DecodeEventRecords(meta *metadata.Metadata, data []byte) ([]*EventRecord, error) {
+	return decodeEventRecords(meta, data, hasTopics(meta))
+}
+
+/*
+V8开始的substrate中EventRecord都有topics
+V7时较早的substrate版本没有topics，topics和System EventTopics storage是一起增加的，按metadata中是否有EventTopics判断
+*/
+func hasTopics(meta *metadata.Metadata) bool {
+	if meta.Version >= 8 {
+		return true
+	}
+	_, err := meta.FindStorage("System", "EventTopics")
+	return err == nil
+}
+
+func decodeEventRecords(meta *metadata.Metadata, data []byte, hasTopics bool) ([]*EventRecord, error) {
+	d := codec.NewTypeDecoder(data)
+	count, err := d.DecodeLength()
+	if err != nil {
+		return nil, fmt.Errorf("decode events length error,err=%v", err)
+	}
+	records := make([]*EventRecord, 0, count)
+	for i := 0; i < count; i++ {
+		record, err := decodeEventRecord(meta, d, hasTopics)
+		if err != nil {
+			return nil, fmt.Errorf("decode event %d error,err=%v", i, err)
+		}
+		records = append(records, record)
+	}
+	if d.Remaining() != 0 {
+		return nil, fmt.Errorf("events has %d extra bytes", d.Remaining())
+	}
+	return records, nil
+}
+
+func decodeEventRecord(meta *metadata.Metadata, d *codec.TypeDecoder, hasTopics bool) (*EventRecord, error) {
+	record := &EventRecord{ExtrinsicIndex: -1}
+	phase, err := d.NextByte()
+	if err != nil {
+		return nil, err
+	}
+	switch phase {
+	case 0:
+		record.Phase = PhaseApplyExtrinsic
+		index, err := d.Decode("u32")
+		if err != nil {
+			return nil, err
+		}
+		record.ExtrinsicIndex = int(index.(uint64))
+	case 1:
+		record.Phase = PhaseFinalization
+	case 2:
+		record.Phase = PhaseInitialization
+	default:
+		return nil, fmt.Errorf("unknown phase %d", phase)
+	}
+	index, err := d.NextBytes(2)
+	if err != nil {
+		return nil, err
+	}
+	e, err := meta.FindEventByIndex(index[0], index[1])
+	if err != nil {
+		return nil, err
+	}
+	record.Module = e.Module
+	record.Event = e.Name
+	record.EventIndex = hex.EncodeToString(index)
+	for _, arg := range e.Args {
+		v, err := d.Decode(arg)
+		if err != nil {
+			return nil, fmt.Errorf("decode event %s.%s error,err=%v", e.Module, e.Name, err)
+		}
+		record.Args = append(record.Args, codec.NamedValue{Type: arg, Value: v})
+	}
+	if hasTopics {
+		topics, err := d.Decode("Vec<Hash>")
+		if err != nil {
+			return nil, err
+		}
+		for _, topic := range topics.([]interface{}) {
+			record.Topics = append(record.Topics, topic.(string))
+		}
+	}
+	return record, nil
+}
@@ -0,0 +1,97 @@
+package events
+
+import (
+	"bytes"
+	"encoding/hex"
+	"github.com/JFJun/chainX-go/metadata"
+	"github.com/JFJun/chainX-go/ss58"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func loadMetadata(t *testing.T, name string) *metadata.Metadata {
+	data, err := ioutil.ReadFile("../metadata/testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := metadata.DecodeHex(strings.TrimSpace(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestDecodeEventRecordsV7(t *testing.T) {
+	m := loadMetadata(t, "chainx_v7.hex")
+	pub := bytes.Repeat([]byte{0x01}, 32)
+	//ChainX 1.0的EventRecord没有topics
+	data := []byte{3 << 2}
+	//ApplyExtrinsic(1) XFeeManager.FeeForProducer(AccountId, 100)
+	data = append(data, 0x00, 0x01, 0x00, 0x00, 0x00, 0x04, 0x01)
+	data = append(data, pub...)
+	data = append(data, 100, 0, 0, 0, 0, 0, 0, 0)
+	//ApplyExtrinsic(1) System.ExtrinsicSuccess
+	data = append(data, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00)
+	//Finalization Session.NewSession(7)
+	data = append(data, 0x01, 0x02, 0x00, 7, 0, 0, 0, 0, 0, 0, 0)
+	records, err := DecodeEventRecordsHex(m, "0x"+hex.EncodeToString(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("records length is %d", len(records))
+	}
+	if r := records[0]; r.Phase != PhaseApplyExtrinsic || r.ExtrinsicIndex != 1 || r.Module != "XFeeManager" || r.Event != "FeeForProducer" || r.EventIndex != "0401" {
+		t.Fatalf("record 0 is %+v", r)
+	}
+	fee, err := records[0].Typed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, _ := ss58.Encode(pub, ss58.ChainXPrefix)
+	if f, ok := fee.(*XFeeManagerFee); !ok || f.Account != account || f.Amount != 100 || f.Event != "FeeForProducer" {
+		t.Fatalf("fee is %+v", fee)
+	}
+	if r := records[1]; r.Module != "System" || r.Event != "ExtrinsicSuccess" || len(r.Args) != 0 {
+		t.Fatalf("record 1 is %+v", r)
+	}
+	if r := records[2]; r.Phase != PhaseFinalization || r.ExtrinsicIndex != -1 || r.Event != "NewSession" || r.Args[0].Value != uint64(7) {
+		t.Fatalf("record 2 is %+v", r)
+	}
+	if _, err := records[2].Typed(); err != ErrEventNotRegistered {
+		t.Fatalf("parse Session.NewSession error is %v", err)
+	}
+
+	//多余的数据和未知的事件返回错误
+	if _, err := DecodeEventRecords(m, append(data, 0x00)); err == nil {
+		t.Fatal("extra bytes: expect error")
+	}
+	if _, err := DecodeEventRecords(m, []byte{1 << 2, 0x01, 0x09, 0x00}); err == nil {
+		t.Fatal("unknown event: expect error")
+	}
+}
+
+func TestDecodeEventRecordsV12(t *testing.T) {
+	m := loadMetadata(t, "substrate_v12.hex")
+	from, to := bytes.Repeat([]byte{0x01}, 32), bytes.Repeat([]byte{0x02}, 32)
+	topic := bytes.Repeat([]byte{0x03}, 32)
+	//Initialization Balances.Transfer(from, to, 5)，一个topic
+	data := []byte{1 << 2, 0x02, 0x05, 0x01}
+	data = append(data, from...)
+	data = append(data, to...)
+	data = append(data, 5, 0, 0, 0, 0, 0, 0, 0)
+	data = append(data, 1<<2)
+	data = append(data, topic...)
+	records, err := DecodeEventRecords(m, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := records[0]
+	if r.Phase != PhaseInitialization || r.Module != "Balances" || r.Event != "Transfer" || r.EventIndex != "0501" || len(r.Args) != 3 {
+		t.Fatalf("record is %+v", r)
+	}
+	if len(r.Topics) != 1 || r.Topics[0] != "0x"+hex.EncodeToString(topic) {
+		t.Fatalf("topics are %v", r.Topics)
+	}
+}
@@ -0,0 +1,102 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+)
+
+var ErrEventNotRegistered = errors.New("event is not registered")
+
+//EventParser 把解析出的EventRecord转换为具体的事件结构体
+type EventParser func(record *EventRecord) (interface{}, error)
+
+var (
+	parserLock sync.RWMutex
+	parsers    = make(map[string]EventParser)
+)
+
+//注册事件的解析函数，module不区分大小写和下划线，例如XFeeManager和xfee_manager相同
+func Register(module, event string, parser EventParser) {
+	parserLock.Lock()
+	defer parserLock.Unlock()
+	parsers[eventKey(module, event)] = parser
+}
+
+/*
+把事件转换为注册的结构体，例如*XAssetsMove
+没有注册的事件返回ErrEventNotRegistered
+*/
+func Parse(record *EventRecord) (interface{}, error) {
+	parserLock.RLock()
+	parser, ok := parsers[eventKey(record.Module, record.Event)]
+	parserLock.RUnlock()
+	if !ok {
+		return nil, ErrEventNotRegistered
+	}
+	v, err := parser(record)
+	if err != nil {
+		return nil, fmt.Errorf("parse event %s.%s error,err=%v", record.Module, record.Event, err)
+	}
+	return v, nil
+}
+
+func (record *EventRecord) Typed() (interface{}, error) {
+	return Parse(record)
+}
+
+func eventKey(module, event string) string {
+	return strings.ToLower(strings.Replace(module, "_", "", -1)) + "." + event
+}
+
+//按位置读取事件参数，参数个数不够或者类型不对时返回错误
+type argReader struct {
+	record *EventRecord
+	err    error
+}
+
+func newArgReader(record *EventRecord, count int) *argReader {
+	r := &argReader{record: record}
+	if len(record.Args) < count {
+		r.err = fmt.Errorf("need %d args but got %d", count, len(record.Args))
+	}
+	return r
+}
+
+func (r *argReader) value(i int) interface{} {
+	if r.err != nil || i >= len(r.record.Args) {
+		return nil
+	}
+	return r.record.Args[i].Value
+}
+
+func (r *argReader) string(i int) string {
+	v := r.value(i)
+	if v == nil {
+		return ""
+	}
+	s, ok := v.(string)
+	if !ok {
+		r.err = fmt.Errorf("arg %d is %T not string", i, v)
+	}
+	return s
+}
+
+func (r *argReader) uint64(i int) uint64 {
+	v := r.value(i)
+	if v == nil {
+		return 0
+	}
+	switch n := v.(type) {
+	case uint64:
+		return n
+	case *big.Int:
+		if n.IsUint64() {
+			return n.Uint64()
+		}
+	}
+	r.err = fmt.Errorf("arg %d is %T not uint64", i, v)
+	return 0
+}
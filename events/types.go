@@ -0,0 +1,237 @@
+package events
+
+/*
+ChainX 1.0运行时的事件，参数顺序和运行时中的定义一致
+地址为ss58地址，Token，AssetType等为字符串
+*/
+
+//XAssets.Move(Token, AccountId, AssetType, AccountId, AssetType, Balance)
+type XAssetsMove struct {
+	Token    string `json:"token"`
+	From     string `json:"from"`
+	FromType string `json:"from_type"`
+	To       string `json:"to"`
+	ToType   string `json:"to_type"`
+	Amount   uint64 `json:"amount"`
+}
+
+//XAssets.Issue(Token, AccountId, Balance)
+type XAssetsIssue struct {
+	Token   string `json:"token"`
+	Account string `json:"account"`
+	Amount  uint64 `json:"amount"`
+}
+
+//XAssets.Destroy(Token, AccountId, Balance)
+type XAssetsDestroy struct {
+	Token   string `json:"token"`
+	Account string `json:"account"`
+	Amount  uint64 `json:"amount"`
+}
+
+//XAssets.Set(Token, AccountId, AssetType, Balance)
+type XAssetsSet struct {
+	Token     string `json:"token"`
+	Account   string `json:"account"`
+	AssetType string `json:"asset_type"`
+	Amount    uint64 `json:"amount"`
+}
+
+//XFeeManager中的手续费分配事件，例如FeeForProducer(AccountId, Balance)
+type XFeeManagerFee struct {
+	Event   string `json:"event"`
+	Account string `json:"account"`
+	Amount  uint64 `json:"amount"`
+}
+
+//XStaking.Nominate(AccountId, AccountId, Balance)
+type XStakingNominate struct {
+	Nominator string `json:"nominator"`
+	Nominee   string `json:"nominee"`
+	Amount    uint64 `json:"amount"`
+}
+
+//XStaking.Unnominate(AccountId, AccountId, Balance)
+type XStakingUnnominate struct {
+	Nominator string `json:"nominator"`
+	Nominee   string `json:"nominee"`
+	Amount    uint64 `json:"amount"`
+}
+
+//XStaking.Claim(AccountId, AccountId, ..., Balance)，金额为最后一个参数
+type XStakingClaim struct {
+	Claimer string `json:"claimer"`
+	Nominee string `json:"nominee"`
+	Amount  uint64 `json:"amount"`
+}
+
+//XStaking.Reward(Balance, Balance)，每个session的奖励总额和验证人的奖励
+type XStakingReward struct {
+	Total      uint64 `json:"total"`
+	Validators uint64 `json:"validators"`
+}
+
+//XStaking.Slash(AccountId, Balance)
+type XStakingSlash struct {
+	Validator string `json:"validator"`
+	Amount    uint64 `json:"amount"`
+}
+
+//XBridgeOfBTC.Deposit(AccountId, Chain, Token, Balance, Memo, AddrStr, Vec<u8>, ...)
+type XBridgeOfBTCDeposit struct {
+	Account string `json:"account"`
+	Chain   string `json:"chain"`
+	Token   string `json:"token"`
+	Amount  uint64 `json:"amount"`
+	Memo    string `json:"memo"`
+	Address string `json:"address"`
+	TxHash  string `json:"tx_hash"`
+}
+
+//XBridgeOfBTC.Withdrawal(u32, Vec<u8>, Vec<u8>, ...)
+type XBridgeOfBTCWithdrawal struct {
+	Id      uint64 `json:"id"`
+	TxHash  string `json:"tx_hash"`
+	Address string `json:"address"`
+}
+
+//XSpot.PutOrder(AccountId, OrderIndex, TradingPairIndex, OrderType, Price, Side, Balance, BlockNumber)
+type XSpotPutOrder struct {
+	Account     string `json:"account"`
+	OrderIndex  uint64 `json:"order_index"`
+	PairIndex   uint64 `json:"pair_index"`
+	OrderType   string `json:"order_type"`
+	Price       uint64 `json:"price"`
+	Side        string `json:"side"`
+	Amount      uint64 `json:"amount"`
+	BlockNumber uint64 `json:"block_number"`
+}
+
+/*
+XSpot.FillOrder(ID, TradingPairIndex, Price, AccountId, AccountId, OrderIndex, OrderIndex, Price, Balance, Balance, BlockNumber)
+第一个Price为成交价格，即maker订单的价格，第二个Price为taker订单的挂单价格
+*/
+type XSpotFillOrder struct {
+	FillIndex       uint64 `json:"fill_index"`
+	PairIndex       uint64 `json:"pair_index"`
+	Price           uint64 `json:"price"`
+	Maker           string `json:"maker"`
+	Taker           string `json:"taker"`
+	MakerOrderIndex uint64 `json:"maker_order_index"`
+	TakerOrderIndex uint64 `json:"taker_order_index"`
+	TakerPrice      uint64 `json:"taker_price"`
+	Amount          uint64 `json:"amount"`
+	TurnOver        uint64 `json:"turn_over"`
+	BlockNumber     uint64 `json:"block_number"`
+}
+
+func init() {
+	Register("XAssets", "Move", func(record *EventRecord) (interface{}, error) {
+		r := newArgReader(record, 6)
+		e := &XAssetsMove{
+			Token:    r.string(0),
+			From:     r.string(1),
+			FromType: r.string(2),
+			To:       r.string(3),
+			ToType:   r.string(4),
+			Amount:   r.uint64(5),
+		}
+		return e, r.err
+	})
+	Register("XAssets", "Issue", func(record *EventRecord) (interface{}, error) {
+		r := newArgReader(record, 3)
+		e := &XAssetsIssue{Token: r.string(0), Account: r.string(1), Amount: r.uint64(2)}
+		return e, r.err
+	})
+	Register("XAssets", "Destroy", func(record *EventRecord) (interface{}, error) {
+		r := newArgReader(record, 3)
+		e := &XAssetsDestroy{Token: r.string(0), Account: r.string(1), Amount: r.uint64(2)}
+		return e, r.err
+	})
+	Register("XAssets", "Set", func(record *EventRecord) (interface{}, error) {
+		r := newArgReader(record, 4)
+		e := &XAssetsSet{Token: r.string(0), Account: r.string(1), AssetType: r.string(2), Amount: r.uint64(3)}
+		return e, r.err
+	})
+	for _, name := range []string{"FeeForProducer", "FeeForJackpot", "FeeForCouncil"} {
+		Register("XFeeManager", name, func(record *EventRecord) (interface{}, error) {
+			r := newArgReader(record, 2)
+			e := &XFeeManagerFee{Event: record.Event, Account: r.string(0), Amount: r.uint64(1)}
+			return e, r.err
+		})
+	}
+	Register("XStaking", "Nominate", func(record *EventRecord) (interface{}, error) {
+		r := newArgReader(record, 3)
+		e := &XStakingNominate{Nominator: r.string(0), Nominee: r.string(1), Amount: r.uint64(2)}
+		return e, r.err
+	})
+	Register("XStaking", "Unnominate", func(record *EventRecord) (interface{}, error) {
+		r := newArgReader(record, 3)
+		e := &XStakingUnnominate{Nominator: r.string(0), Nominee: r.string(1), Amount: r.uint64(2)}
+		return e, r.err
+	})
+	Register("XStaking", "Claim", func(record *EventRecord) (interface{}, error) {
+		r := newArgReader(record, 3)
+		e := &XStakingClaim{Claimer: r.string(0), Nominee: r.string(1), Amount: r.uint64(len(record.Args) - 1)}
+		return e, r.err
+	})
+	Register("XStaking", "Reward", func(record *EventRecord) (interface{}, error) {
+		r := newArgReader(record, 2)
+		e := &XStakingReward{Total: r.uint64(0), Validators: r.uint64(1)}
+		return e, r.err
+	})
+	Register("XStaking", "Slash", func(record *EventRecord) (interface{}, error) {
+		r := newArgReader(record, 2)
+		e := &XStakingSlash{Validator: r.string(0), Amount: r.uint64(1)}
+		return e, r.err
+	})
+	Register("XBridgeOfBTC", "Deposit", func(record *EventRecord) (interface{}, error) {
+		r := newArgReader(record, 7)
+		e := &XBridgeOfBTCDeposit{
+			Account: r.string(0),
+			Chain:   r.string(1),
+			Token:   r.string(2),
+			Amount:  r.uint64(3),
+			Memo:    r.string(4),
+			Address: r.string(5),
+			TxHash:  r.string(6),
+		}
+		return e, r.err
+	})
+	Register("XBridgeOfBTC", "Withdrawal", func(record *EventRecord) (interface{}, error) {
+		r := newArgReader(record, 3)
+		e := &XBridgeOfBTCWithdrawal{Id: r.uint64(0), TxHash: r.string(1), Address: r.string(2)}
+		return e, r.err
+	})
+	Register("XSpot", "PutOrder", func(record *EventRecord) (interface{}, error) {
+		r := newArgReader(record, 8)
+		e := &XSpotPutOrder{
+			Account:     r.string(0),
+			OrderIndex:  r.uint64(1),
+			PairIndex:   r.uint64(2),
+			OrderType:   r.string(3),
+			Price:       r.uint64(4),
+			Side:        r.string(5),
+			Amount:      r.uint64(6),
+			BlockNumber: r.uint64(7),
+		}
+		return e, r.err
+	})
+	Register("XSpot", "FillOrder", func(record *EventRecord) (interface{}, error) {
+		r := newArgReader(record, 11)
+		e := &XSpotFillOrder{
+			FillIndex:       r.uint64(0),
+			PairIndex:       r.uint64(1),
+			Price:           r.uint64(2),
+			Maker:           r.string(3),
+			Taker:           r.string(4),
+			MakerOrderIndex: r.uint64(5),
+			TakerOrderIndex: r.uint64(6),
+			TakerPrice:      r.uint64(7),
+			Amount:          r.uint64(8),
+			TurnOver:        r.uint64(9),
+			BlockNumber:     r.uint64(10),
+		}
+		return e, r.err
+	})
+}
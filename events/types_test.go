@@ -0,0 +1,29 @@
+package events
+
+import (
+	codec "github.com/JFJun/chainX-go/codes"
+	"testing"
+)
+
+func TestParseXSpotFillOrder(t *testing.T) {
+	values := []interface{}{uint64(1), uint64(2), uint64(100), "maker", "taker", uint64(3), uint64(4), uint64(101), uint64(5), uint64(500), uint64(6)}
+	record := &EventRecord{Module: "XSpot", Event: "FillOrder"}
+	for _, v := range values {
+		record.Args = append(record.Args, codec.NamedValue{Value: v})
+	}
+	e, err := Parse(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := XSpotFillOrder{FillIndex: 1, PairIndex: 2, Price: 100, Maker: "maker", Taker: "taker", MakerOrderIndex: 3,
+		TakerOrderIndex: 4, TakerPrice: 101, Amount: 5, TurnOver: 500, BlockNumber: 6}
+	if f, ok := e.(*XSpotFillOrder); !ok || *f != expect {
+		t.Fatalf("fill order is %+v", e)
+	}
+
+	//参数个数不对时返回错误
+	record.Args = record.Args[:10]
+	if _, err := Parse(record); err == nil {
+		t.Fatal("missing arg: expect error")
+	}
+}
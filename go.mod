@@ -9,6 +9,9 @@ require github.com/pierrec/xxHash v0.1.5
 require golang.org/x/crypto v0.0.0-20200604202706-70a84ac30bf9
 
 require (
+	github.com/ChainSafe/go-schnorrkel v0.0.0-20200405005733-88cbf1b4c40d
+	github.com/fxamacker/cbor/v2 v2.2.0
+	github.com/gorilla/websocket v1.4.2
 	github.com/itering/scale.go v0.2.3
 	github.com/shopspring/decimal v1.2.0
 )
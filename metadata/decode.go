@@ -0,0 +1,284 @@
+package metadata
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/JFJun/chainX-go/util"
+)
+
+//metadata开头的magic number "meta"
+const magicNumber = 0x6174656d
+
+const (
+	MinVersion = 7
+	MaxVersion = 12
+)
+
+//metadata的版本不在MinVersion和MaxVersion之间
+var ErrUnsupportedVersion = errors.New("not support metadata version")
+
+//不同版本storage hasher枚举的顺序不同
+var (
+	hashersV7 = []string{"Blake2_128", "Blake2_256", "Twox128", "Twox256", "Twox64Concat"}
+	//V10增加了Blake2_128Concat
+	hashersV10 = []string{"Blake2_128", "Blake2_256", "Blake2_128Concat", "Twox128", "Twox256", "Twox64Concat"}
+	//V11增加了Identity
+	hashersV11 = []string{"Blake2_128", "Blake2_256", "Blake2_128Concat", "Twox128", "Twox256", "Twox64Concat", "Identity"}
+)
+
+type decoder struct {
+	*util.Decoder
+	version int
+}
+
+//解析state_getMetadata返回的hex
+func DecodeHex(metadataHex string) (*Metadata, error) {
+	data, err := hex.DecodeString(util.RemoveHex0x(metadataHex))
+	if err != nil {
+		return nil, fmt.Errorf("decode metadata hex error,err=%v", err)
+	}
+	return Decode(data)
+}
+
+func Decode(data []byte) (*Metadata, error) {
+	pd := &decoder{Decoder: util.NewDecoder(bytes.NewReader(data))}
+	var magic uint32
+	if err := pd.Decode(&magic); err != nil {
+		return nil, fmt.Errorf("decode metadata magic number error,err=%v", err)
+	}
+	if magic != magicNumber {
+		return nil, errors.New("metadata magic number is not 'meta'")
+	}
+	version, err := pd.ReadOneByte()
+	if err != nil {
+		return nil, fmt.Errorf("decode metadata version error,err=%v", err)
+	}
+	if version < MinVersion || version > MaxVersion {
+		return nil, fmt.Errorf("%w %d", ErrUnsupportedVersion, version)
+	}
+	pd.version = int(version)
+	m := &Metadata{Version: pd.version}
+	count, err := pd.DecodeUintCompact()
+	if err != nil {
+		return nil, fmt.Errorf("decode metadata modules error,err=%v", err)
+	}
+	for i := uint64(0); i < count; i++ {
+		mod, err := pd.decodeModule()
+		if err != nil {
+			return nil, fmt.Errorf("decode metadata module %d error,err=%v", i, err)
+		}
+		m.Modules = append(m.Modules, mod)
+	}
+	if pd.version >= 11 {
+		m.Extrinsic = new(ExtrinsicMetadata)
+		if err := pd.Decode(m.Extrinsic); err != nil {
+			return nil, fmt.Errorf("decode extrinsic metadata error,err=%v", err)
+		}
+	}
+	m.setIndex()
+	return m, nil
+}
+
+/*
+V12之前module index是按顺序计算的：
+交易的module index只计算有calls的module，事件的module index只计算有events的module
+V12开始module中直接保存了index
+*/
+func (m *Metadata) setIndex() {
+	var callIndex, eventIndex uint8
+	for _, mod := range m.Modules {
+		if m.Version < 12 {
+			if mod.HasCalls {
+				mod.CallIndex = callIndex
+				callIndex++
+			}
+			if mod.HasEvents {
+				mod.EventIndex = eventIndex
+				eventIndex++
+			}
+		}
+		for i := range mod.Calls {
+			mod.Calls[i].Module = mod.Name
+			mod.Calls[i].Index = [2]byte{mod.CallIndex, uint8(i)}
+		}
+		for i := range mod.Events {
+			mod.Events[i].Module = mod.Name
+			mod.Events[i].Index = [2]byte{mod.EventIndex, uint8(i)}
+		}
+	}
+}
+
+func (pd *decoder) decodeModule() (*Module, error) {
+	mod := new(Module)
+	if err := pd.Decode(&mod.Name); err != nil {
+		return nil, err
+	}
+	hasStorage, err := pd.decodeOptionFlag()
+	if err != nil {
+		return nil, err
+	}
+	if hasStorage {
+		if mod.Storage, err = pd.decodeStorage(); err != nil {
+			return nil, fmt.Errorf("module %s storage error,err=%v", mod.Name, err)
+		}
+	}
+	if mod.HasCalls, err = pd.decodeOptionFlag(); err != nil {
+		return nil, err
+	}
+	if mod.HasCalls {
+		if err := pd.Decode(&mod.Calls); err != nil {
+			return nil, fmt.Errorf("module %s calls error,err=%v", mod.Name, err)
+		}
+	}
+	if mod.HasEvents, err = pd.decodeOptionFlag(); err != nil {
+		return nil, err
+	}
+	if mod.HasEvents {
+		if err := pd.Decode(&mod.Events); err != nil {
+			return nil, fmt.Errorf("module %s events error,err=%v", mod.Name, err)
+		}
+	}
+	if err := pd.Decode(&mod.Constants); err != nil {
+		return nil, fmt.Errorf("module %s constants error,err=%v", mod.Name, err)
+	}
+	if pd.version >= 8 {
+		if err := pd.Decode(&mod.Errors); err != nil {
+			return nil, fmt.Errorf("module %s errors error,err=%v", mod.Name, err)
+		}
+	}
+	if pd.version >= 12 {
+		index, err := pd.ReadOneByte()
+		if err != nil {
+			return nil, fmt.Errorf("module %s index error,err=%v", mod.Name, err)
+		}
+		mod.CallIndex = index
+		mod.EventIndex = index
+	}
+	return mod, nil
+}
+
+func (pd *decoder) decodeStorage() (*Storage, error) {
+	s := new(Storage)
+	if err := pd.Decode(&s.Prefix); err != nil {
+		return nil, err
+	}
+	count, err := pd.DecodeUintCompact()
+	if err != nil {
+		return nil, err
+	}
+	for i := uint64(0); i < count; i++ {
+		entry, err := pd.decodeStorageEntry()
+		if err != nil {
+			return nil, err
+		}
+		entry.Prefix = s.Prefix
+		s.Entries = append(s.Entries, entry)
+	}
+	return s, nil
+}
+
+func (pd *decoder) decodeStorageEntry() (*StorageEntry, error) {
+	entry := new(StorageEntry)
+	if err := pd.Decode(&entry.Name); err != nil {
+		return nil, err
+	}
+	modifier, err := pd.ReadOneByte()
+	if err != nil {
+		return nil, err
+	}
+	switch modifier {
+	case 0:
+		entry.Modifier = "Optional"
+	case 1:
+		entry.Modifier = "Default"
+	default:
+		return nil, fmt.Errorf("storage %s unknown modifier %d", entry.Name, modifier)
+	}
+	typ, err := pd.ReadOneByte()
+	if err != nil {
+		return nil, err
+	}
+	switch typ {
+	case 0:
+		entry.Type = StoragePlain
+		if err := pd.Decode(&entry.Value); err != nil {
+			return nil, err
+		}
+	case 1:
+		entry.Type = StorageMap
+		if entry.Hasher, err = pd.decodeHasher(); err != nil {
+			return nil, err
+		}
+		if err := pd.Decode(&entry.Key); err != nil {
+			return nil, err
+		}
+		if err := pd.Decode(&entry.Value); err != nil {
+			return nil, err
+		}
+		//V11之后这个字段没有使用
+		if err := pd.Decode(&entry.IsLinked); err != nil {
+			return nil, err
+		}
+	case 2:
+		entry.Type = StorageDoubleMap
+		if entry.Hasher, err = pd.decodeHasher(); err != nil {
+			return nil, err
+		}
+		if err := pd.Decode(&entry.Key); err != nil {
+			return nil, err
+		}
+		if err := pd.Decode(&entry.Key2); err != nil {
+			return nil, err
+		}
+		if err := pd.Decode(&entry.Value); err != nil {
+			return nil, err
+		}
+		if entry.Key2Hasher, err = pd.decodeHasher(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("storage %s unknown type %d", entry.Name, typ)
+	}
+	if err := pd.Decode(&entry.Default); err != nil {
+		return nil, err
+	}
+	if err := pd.Decode(&entry.Docs); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (pd *decoder) decodeHasher() (string, error) {
+	hashers := hashersV7
+	if pd.version >= 11 {
+		hashers = hashersV11
+	} else if pd.version >= 10 {
+		hashers = hashersV10
+	}
+	b, err := pd.ReadOneByte()
+	if err != nil {
+		return "", err
+	}
+	if int(b) >= len(hashers) {
+		return "", fmt.Errorf("unknown storage hasher %d", b)
+	}
+	return hashers[b], nil
+}
+
+//解析Option的标志位
+func (pd *decoder) decodeOptionFlag() (bool, error) {
+	b, err := pd.ReadOneByte()
+	if err != nil {
+		return false, err
+	}
+	switch b {
+	case 0:
+		return false, nil
+	case 1:
+		return true, nil
+	default:
+		return false, fmt.Errorf("unknown option flag %d", b)
+	}
+}
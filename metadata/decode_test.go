@@ -0,0 +1,281 @@
+package metadata
+
+import (
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+/*
+testdata中的metadata按照V7和V12的格式编码，只保留了部分module
+chainx_v7.hex: ChainX 1.0的module顺序，XAssets.transfer为0803，Timestamp.set为0100
+substrate_v12.hex: module中保存index，index不连续
+*/
+func loadFixture(t *testing.T, name string) *Metadata {
+	data, err := ioutil.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := DecodeHex(strings.TrimSpace(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestDecodeV7(t *testing.T) {
+	m := loadFixture(t, "chainx_v7.hex")
+	if m.Version != 7 || len(m.Modules) != 11 || m.Extrinsic != nil {
+		t.Fatalf("version %d modules %d extrinsic %v", m.Version, len(m.Modules), m.Extrinsic)
+	}
+	//module index只计算有calls的module
+	calls := map[string]string{
+		"System.remark":                 "0000",
+		"Timestamp.set":                 "0100",
+		"FinalityTracker.final_hint":    "0400",
+		"XAccounts.register":            "0700",
+		"XAssets.set_balance":           "0800",
+		"XAssets.transfer":              "0803",
+		"xassets.TRANSFER":              "0803",
+		"XAssets.modify_asset_limit":    "0802",
+		"Consensus.report_misbehavior":  "0200",
+		"Grandpa.report_misbehavior":    "0500",
+		"XSystem.set_block_producer":    "0600",
+		"Session.set_key":               "0300",
+		"XAssets.set_asset_limit_props": "0801",
+	}
+	for name, expect := range calls {
+		parts := strings.Split(name, ".")
+		index, err := m.CallIndex(parts[0], parts[1])
+		if err != nil || index != expect {
+			t.Errorf("%s call index is %s,expect %s,err=%v", name, index, expect, err)
+		}
+	}
+	if _, err := m.CallIndex("Indices", "transfer"); err == nil {
+		t.Error("Indices has no calls: expect error")
+	}
+	call, err := m.FindCallByIndex(8, 3)
+	if err != nil || call.Module != "XAssets" || call.Name != "transfer" || len(call.Args) != 4 {
+		t.Fatalf("call 0803 is %+v,err=%v", call, err)
+	}
+	if call.Args[2].Name != "value" || call.Args[2].Type != "Balance" || call.Docs[0] != " transfer between account" {
+		t.Fatalf("call args %+v docs %v", call.Args, call.Docs)
+	}
+	if _, err := m.FindCallByIndex(8, 4); err == nil {
+		t.Error("call 0804: expect error")
+	}
+
+	//事件的module index只计算有events的module
+	events := map[string][2]byte{
+		"System.ExtrinsicFailed":     {0, 1},
+		"Indices.NewAccountIndex":    {1, 0},
+		"Session.NewSession":         {2, 0},
+		"Grandpa.NewAuthorities":     {3, 0},
+		"XFeeManager.FeeForProducer": {4, 1},
+		"XAssets.Move":               {5, 0},
+		"XAssets.Set":                {5, 3},
+	}
+	for name, expect := range events {
+		parts := strings.Split(name, ".")
+		e, err := m.FindEvent(parts[0], parts[1])
+		if err != nil || e.Index != expect {
+			t.Errorf("%s event index is %v,expect %v,err=%v", name, e, expect, err)
+			continue
+		}
+		found, err := m.FindEventByIndex(expect[0], expect[1])
+		if err != nil || found != e {
+			t.Errorf("event %v is %+v,err=%v", expect, found, err)
+		}
+	}
+	e, _ := m.FindEvent("XAssets", "Move")
+	if strings.Join(e.Args, ",") != "Token,AccountId,AssetType,AccountId,AssetType,Balance" {
+		t.Fatalf("XAssets.Move args are %v", e.Args)
+	}
+
+	entry, err := m.FindStorage("System", "AccountNonce")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Prefix != "System" || entry.Type != StorageMap || entry.Hasher != "Blake2_256" || entry.Key != "AccountId" ||
+		entry.Value != "Index" || entry.Modifier != "Default" || len(entry.Default) != 8 || len(entry.Docs) != 1 {
+		t.Fatalf("System.AccountNonce is %+v", entry)
+	}
+	if _, err := m.FindStorage("System", "EventTopics"); err == nil {
+		t.Fatal("System.EventTopics: expect error")
+	}
+	c, err := m.FindConstant("Timestamp", "BlockPeriod")
+	if err != nil || c.Type != "Moment" || len(c.Value) != 8 || c.Value[0] != 1 {
+		t.Fatalf("Timestamp.BlockPeriod is %+v,err=%v", c, err)
+	}
+}
+
+func TestDecodeV12(t *testing.T) {
+	m := loadFixture(t, "substrate_v12.hex")
+	if m.Version != 12 || len(m.Modules) != 5 {
+		t.Fatalf("version %d modules %d", m.Version, len(m.Modules))
+	}
+	if m.Extrinsic == nil || m.Extrinsic.Version != 4 || len(m.Extrinsic.SignedExtensions) != 7 ||
+		m.Extrinsic.SignedExtensions[4] != "CheckNonce" {
+		t.Fatalf("extrinsic metadata is %+v", m.Extrinsic)
+	}
+	//V12的module index由metadata指定，没有calls的module也占用index
+	for name, expect := range map[string]string{"System.remark": "0000", "Timestamp.set": "0200", "Balances.transfer": "0500", "XAssets.transfer": "0800"} {
+		parts := strings.Split(name, ".")
+		index, err := m.CallIndex(parts[0], parts[1])
+		if err != nil || index != expect {
+			t.Errorf("%s call index is %s,expect %s,err=%v", name, index, expect, err)
+		}
+	}
+	if call, err := m.FindCallByIndex(5, 0); err != nil || call.Name != "transfer" || call.Module != "Balances" {
+		t.Fatalf("call 0500 is %+v,err=%v", call, err)
+	}
+	if _, err := m.FindCallByIndex(1, 0); err == nil {
+		t.Fatal("call 0100: expect error")
+	}
+	if e, err := m.FindEventByIndex(5, 1); err != nil || e.Name != "Transfer" {
+		t.Fatalf("event 0501 is %+v,err=%v", e, err)
+	}
+	if e, err := m.FindEventByIndex(8, 0); err != nil || e.Module != "XAssets" || e.Name != "Moved" {
+		t.Fatalf("event 0800 is %+v,err=%v", e, err)
+	}
+
+	mod, err := m.FindModule("balances")
+	if err != nil || len(mod.Errors) != 1 || mod.Errors[0].Name != "InsufficientBalance" {
+		t.Fatalf("Balances errors are %+v,err=%v", mod, err)
+	}
+	entry, err := m.FindStorage("XAssets", "AssetBalance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Type != StorageDoubleMap || entry.Hasher != "Blake2_128Concat" || entry.Key != "AccountId" ||
+		entry.Key2 != "u32" || entry.Key2Hasher != "Twox64Concat" || entry.Value != "BTreeMap<AssetType, Balance>" {
+		t.Fatalf("XAssets.AssetBalance is %+v", entry)
+	}
+	if entry, err := m.FindStorage("System", "Account"); err != nil || entry.Hasher != "Blake2_128Concat" {
+		t.Fatalf("System.Account is %+v,err=%v", entry, err)
+	}
+}
+
+//一个module，只有一个Map storage，用来测试不同版本的hasher
+func metadataWithHasher(version, hasher byte) []byte {
+	data := []byte("meta")
+	data = append(data, version, 1<<2)
+	data = append(data, 2<<2, 'T', 'e')
+	//storage: prefix "Te"，一个entry "K"，Default，Map
+	data = append(data, 1, 2<<2, 'T', 'e', 1<<2)
+	data = append(data, 1<<2, 'K', 1, 1, hasher)
+	data = append(data, 2<<2, 'u', '8', 2<<2, 'u', '8', 0)
+	data = append(data, 0, 0)
+	//calls,events,constants
+	data = append(data, 0, 0, 0)
+	if version >= 8 {
+		data = append(data, 0)
+	}
+	if version >= 12 {
+		data = append(data, 0)
+	}
+	if version >= 11 {
+		data = append(data, 4, 0)
+	}
+	return data
+}
+
+func TestDecodeHasher(t *testing.T) {
+	tests := []struct {
+		version byte
+		hasher  byte
+		expect  string
+	}{
+		{7, 0, "Blake2_128"},
+		{7, 1, "Blake2_256"},
+		{7, 2, "Twox128"},
+		{7, 3, "Twox256"},
+		{7, 4, "Twox64Concat"},
+		{7, 5, ""},
+		{9, 2, "Twox128"},
+		//V10在Blake2_256之后增加了Blake2_128Concat
+		{10, 2, "Blake2_128Concat"},
+		{10, 3, "Twox128"},
+		{10, 5, "Twox64Concat"},
+		{10, 6, ""},
+		//V11增加了Identity
+		{11, 2, "Blake2_128Concat"},
+		{11, 6, "Identity"},
+		{11, 7, ""},
+		{12, 5, "Twox64Concat"},
+		{12, 6, "Identity"},
+	}
+	for _, test := range tests {
+		m, err := Decode(metadataWithHasher(test.version, test.hasher))
+		if test.expect == "" {
+			if err == nil {
+				t.Errorf("V%d hasher %d: expect error", test.version, test.hasher)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("V%d hasher %d: %v", test.version, test.hasher, err)
+			continue
+		}
+		if entry := m.Modules[0].Storage.Entries[0]; entry.Hasher != test.expect {
+			t.Errorf("V%d hasher %d is %s,expect %s", test.version, test.hasher, entry.Hasher, test.expect)
+		}
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	valid := metadataWithHasher(12, 0)
+	tests := map[string][]byte{
+		"magic":       append([]byte("atem"), valid[4:]...),
+		"version 6":   append([]byte("meta\x06"), valid[5:]...),
+		"version 13":  append([]byte("meta\x0d"), valid[5:]...),
+		"truncated":   valid[:len(valid)/2],
+		"option flag": append([]byte("meta\x0c\x04\x08Te\x02"), valid[10:]...),
+		"empty":       nil,
+	}
+	for name, data := range tests {
+		if _, err := Decode(data); err == nil {
+			t.Errorf("%s: expect error", name)
+		}
+	}
+	for _, version := range []byte{6, 13} {
+		if _, err := Decode(append([]byte("meta"), version, 0)); !errors.Is(err, ErrUnsupportedVersion) {
+			t.Errorf("version %d error is %v", version, err)
+		}
+	}
+	if _, err := DecodeHex("0xzz"); err == nil {
+		t.Error("invalid hex: expect error")
+	}
+}
+
+func TestSetIndex(t *testing.T) {
+	newModules := func() []*Module {
+		return []*Module{
+			{Name: "A", HasCalls: true, Calls: []Call{{Name: "a0"}, {Name: "a1"}}, CallIndex: 3, EventIndex: 3},
+			{Name: "B", HasEvents: true, Events: []Event{{Name: "b0"}}, CallIndex: 7, EventIndex: 7},
+			{Name: "C", HasCalls: true, HasEvents: true, Calls: []Call{{Name: "c0"}}, Events: []Event{{Name: "c0"}}, CallIndex: 9, EventIndex: 9},
+		}
+	}
+	//V12之前按顺序计算，忽略已有的index
+	m := &Metadata{Version: 11, Modules: newModules()}
+	m.setIndex()
+	if m.Modules[0].Calls[1].Index != [2]byte{0, 1} || m.Modules[2].Calls[0].Index != [2]byte{1, 0} {
+		t.Fatalf("V11 call index %v %v", m.Modules[0].Calls[1].Index, m.Modules[2].Calls[0].Index)
+	}
+	if m.Modules[1].Events[0].Index != [2]byte{0, 0} || m.Modules[2].Events[0].Index != [2]byte{1, 0} {
+		t.Fatalf("V11 event index %v %v", m.Modules[1].Events[0].Index, m.Modules[2].Events[0].Index)
+	}
+	if m.Modules[2].Calls[0].Module != "C" || m.Modules[1].Events[0].Module != "B" {
+		t.Fatal("module name is not set")
+	}
+	//V12使用metadata中的index
+	m = &Metadata{Version: 12, Modules: newModules()}
+	m.setIndex()
+	if m.Modules[0].Calls[1].Index != [2]byte{3, 1} || m.Modules[2].Calls[0].Index != [2]byte{9, 0} {
+		t.Fatalf("V12 call index %v %v", m.Modules[0].Calls[1].Index, m.Modules[2].Calls[0].Index)
+	}
+	if m.Modules[1].Events[0].Index != [2]byte{7, 0} || m.Modules[2].Events[0].Index != [2]byte{9, 0} {
+		t.Fatalf("V12 event index %v %v", m.Modules[1].Events[0].Index, m.Modules[2].Events[0].Index)
+	}
+}
@@ -0,0 +1,200 @@
+package metadata
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+/*
+Metadata 节点运行时的metadata，由state_getMetadata返回的数据解析得到
+支持V7到V12版本，ChainX 1.0为V7，V8开始storage key增加了module前缀
+*/
+type Metadata struct {
+	Version   int                `json:"version"`
+	Modules   []*Module          `json:"modules"`
+	Extrinsic *ExtrinsicMetadata `json:"extrinsic,omitempty"` //V11开始才有
+}
+
+type Module struct {
+	Name       string     `json:"name"`
+	CallIndex  uint8      `json:"call_index"`  //交易中使用的module index
+	EventIndex uint8      `json:"event_index"` //事件中使用的module index
+	HasCalls   bool       `json:"has_calls"`
+	HasEvents  bool       `json:"has_events"`
+	Storage    *Storage   `json:"storage,omitempty"`
+	Calls      []Call     `json:"calls"`
+	Events     []Event    `json:"events"`
+	Constants  []Constant `json:"constants"`
+	Errors     []Error    `json:"errors"` //V8开始才有
+}
+
+type Storage struct {
+	Prefix  string          `json:"prefix"`
+	Entries []*StorageEntry `json:"entries"`
+}
+
+type StorageEntry struct {
+	Prefix     string   `json:"prefix"` //所属module的storage prefix
+	Name       string   `json:"name"`
+	Modifier   string   `json:"modifier"` //Optional or Default
+	Type       string   `json:"type"`     //Plain Map or DoubleMap
+	Hasher     string   `json:"hasher"`
+	Key        string   `json:"key"`
+	Key2       string   `json:"key2"`
+	Key2Hasher string   `json:"key2_hasher"`
+	Value      string   `json:"value"`
+	IsLinked   bool     `json:"is_linked"`
+	Default    []byte   `json:"default"`
+	Docs       []string `json:"docs"`
+}
+
+type Call struct {
+	Name   string   `json:"name"`
+	Args   []Arg    `json:"args"`
+	Docs   []string `json:"docs"`
+	Module string   `json:"module" scale:"-"`
+	Index  [2]byte  `json:"index" scale:"-"` //module index和call index
+}
+
+type Arg struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type Event struct {
+	Name   string   `json:"name"`
+	Args   []string `json:"args"`
+	Docs   []string `json:"docs"`
+	Module string   `json:"module" scale:"-"`
+	Index  [2]byte  `json:"index" scale:"-"`
+}
+
+type Constant struct {
+	Name  string   `json:"name"`
+	Type  string   `json:"type"`
+	Value []byte   `json:"value"`
+	Docs  []string `json:"docs"`
+}
+
+type Error struct {
+	Name string   `json:"name"`
+	Docs []string `json:"docs"`
+}
+
+type ExtrinsicMetadata struct {
+	Version          uint8    `json:"version"`
+	SignedExtensions []string `json:"signed_extensions"`
+}
+
+const (
+	StoragePlain     = "Plain"
+	StorageMap       = "Map"
+	StorageDoubleMap = "DoubleMap"
+)
+
+//按名字查找module，不区分大小写
+func (m *Metadata) FindModule(module string) (*Module, error) {
+	for _, mod := range m.Modules {
+		if strings.EqualFold(mod.Name, module) {
+			return mod, nil
+		}
+	}
+	return nil, fmt.Errorf("module %s is not found in metadata", module)
+}
+
+//按名字查找call，返回的Call.Index就是交易中的call id
+func (m *Metadata) FindCall(module, call string) (*Call, error) {
+	mod, err := m.FindModule(module)
+	if err != nil {
+		return nil, err
+	}
+	for i := range mod.Calls {
+		if strings.EqualFold(mod.Calls[i].Name, call) {
+			return &mod.Calls[i], nil
+		}
+	}
+	return nil, fmt.Errorf("call %s.%s is not found in metadata", module, call)
+}
+
+//返回和CallIdTransfer相同格式的call id，例如XAssets.transfer返回"0803"
+func (m *Metadata) CallIndex(module, call string) (string, error) {
+	c, err := m.FindCall(module, call)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(c.Index[:]), nil
+}
+
+//按交易中的module index和call index查找call
+func (m *Metadata) FindCallByIndex(moduleIndex, callIndex uint8) (*Call, error) {
+	for _, mod := range m.Modules {
+		if !mod.HasCalls || mod.CallIndex != moduleIndex {
+			continue
+		}
+		if int(callIndex) >= len(mod.Calls) {
+			break
+		}
+		return &mod.Calls[callIndex], nil
+	}
+	return nil, fmt.Errorf("call index %d-%d is not found in metadata", moduleIndex, callIndex)
+}
+
+func (m *Metadata) FindEvent(module, event string) (*Event, error) {
+	mod, err := m.FindModule(module)
+	if err != nil {
+		return nil, err
+	}
+	for i := range mod.Events {
+		if strings.EqualFold(mod.Events[i].Name, event) {
+			return &mod.Events[i], nil
+		}
+	}
+	return nil, fmt.Errorf("event %s.%s is not found in metadata", module, event)
+}
+
+//按事件中的module index和event index查找event
+func (m *Metadata) FindEventByIndex(moduleIndex, eventIndex uint8) (*Event, error) {
+	for _, mod := range m.Modules {
+		if !mod.HasEvents || mod.EventIndex != moduleIndex {
+			continue
+		}
+		if int(eventIndex) >= len(mod.Events) {
+			break
+		}
+		return &mod.Events[eventIndex], nil
+	}
+	return nil, fmt.Errorf("event index %d-%d is not found in metadata", moduleIndex, eventIndex)
+}
+
+//metadata中没有这个module或者storage
+var ErrStorageNotFound = errors.New("storage is not found in metadata")
+
+func (m *Metadata) FindStorage(module, item string) (*StorageEntry, error) {
+	mod, err := m.FindModule(module)
+	if err != nil {
+		return nil, fmt.Errorf("%w %s.%s", ErrStorageNotFound, module, item)
+	}
+	if mod.Storage != nil {
+		for _, entry := range mod.Storage.Entries {
+			if strings.EqualFold(entry.Name, item) {
+				return entry, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%w %s.%s", ErrStorageNotFound, module, item)
+}
+
+func (m *Metadata) FindConstant(module, name string) (*Constant, error) {
+	mod, err := m.FindModule(module)
+	if err != nil {
+		return nil, err
+	}
+	for i := range mod.Constants {
+		if strings.EqualFold(mod.Constants[i].Name, name) {
+			return &mod.Constants[i], nil
+		}
+	}
+	return nil, fmt.Errorf("constant %s.%s is not found in metadata", module, name)
+}
@@ -0,0 +1,94 @@
+package metadata
+
+import (
+	"fmt"
+	codec "github.com/JFJun/chainX-go/codes"
+	"github.com/JFJun/chainX-go/util"
+	"github.com/JFJun/chainX-go/xxhash"
+)
+
+/*
+根据metadata生成storage的key
+V8之前:
+	Plain: twox128(prefix+" "+name)
+	Map: hasher(prefix+" "+name+key)
+	DoubleMap: hasher(prefix+" "+name+key1) + key2hasher(key2)
+V8开始:
+	Plain: twox128(prefix) + twox128(name)
+	Map: twox128(prefix) + twox128(name) + hasher(key)
+	DoubleMap: twox128(prefix) + twox128(name) + hasher(key1) + key2hasher(key2)
+key按照storage中定义的类型编码，例如AccountId可以是ss58地址或者公钥
+*/
+func (m *Metadata) StorageKey(module, item string, keys ...interface{}) ([]byte, *StorageEntry, error) {
+	entry, err := m.FindStorage(module, item)
+	if err != nil {
+		return nil, nil, err
+	}
+	var need int
+	switch entry.Type {
+	case StoragePlain:
+		need = 0
+	case StorageMap:
+		need = 1
+	case StorageDoubleMap:
+		need = 2
+	default:
+		return nil, nil, fmt.Errorf("unknown storage type %s", entry.Type)
+	}
+	if len(keys) != need {
+		return nil, nil, fmt.Errorf("storage %s.%s need %d keys but got %d", entry.Prefix, entry.Name, need, len(keys))
+	}
+	var key1, key2 []byte
+	if need > 0 {
+		key1, err = codec.EncodeType(entry.Key, keys[0])
+		if err != nil {
+			return nil, nil, fmt.Errorf("encode storage key %s error,err=%v", entry.Key, err)
+		}
+	}
+	if need > 1 {
+		key2, err = codec.EncodeType(entry.Key2, keys[1])
+		if err != nil {
+			return nil, nil, fmt.Errorf("encode storage key2 %s error,err=%v", entry.Key2, err)
+		}
+	}
+	var key []byte
+	if m.Version < 8 {
+		prefix := []byte(entry.Prefix + " " + entry.Name)
+		if need == 0 {
+			key = xxhash.New128(prefix).Sum(nil)
+		} else {
+			key, err = hashKey(entry.Hasher, append(prefix, key1...))
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+	} else {
+		key = append(xxhash.New128([]byte(entry.Prefix)).Sum(nil), xxhash.New128([]byte(entry.Name)).Sum(nil)...)
+		if need > 0 {
+			k, err := hashKey(entry.Hasher, key1)
+			if err != nil {
+				return nil, nil, err
+			}
+			key = append(key, k...)
+		}
+	}
+	if need > 1 {
+		k, err := hashKey(entry.Key2Hasher, key2)
+		if err != nil {
+			return nil, nil, err
+		}
+		key = append(key, k...)
+	}
+	return key, entry, nil
+}
+
+func hashKey(hasher string, data []byte) ([]byte, error) {
+	h, err := util.SelectHash(hasher)
+	if err != nil {
+		return nil, fmt.Errorf("select hasher %s error,err=%v", hasher, err)
+	}
+	if _, err := h.Write(data); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
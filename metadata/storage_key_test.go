@@ -0,0 +1,95 @@
+package metadata
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+//Alice的公钥
+const testPublicKey = "0xd43593c715fdd31c61141abd04a99fd6822c8558854ccde39a5684e7a56da27d"
+
+func TestStorageKeyV7(t *testing.T) {
+	m := loadFixture(t, "chainx_v7.hex")
+	tests := []struct {
+		module string
+		item   string
+		keys   []interface{}
+		expect string
+	}{
+		//twox128("System Events")
+		{"System", "Events", nil, "cc956bdb7605e3547539f321ac2bc95c"},
+		//blake2_256("System AccountNonce" + 公钥)
+		{"System", "AccountNonce", []interface{}{testPublicKey}, "5c54163a1c72509b5250f0a30b9001fdee9d9b48388b06921f1b210e81e3a1f0"},
+		//blake2_256("XAssets AssetBalance" + 公钥 + Token)
+		{"XAssets", "AssetBalance", []interface{}{[]interface{}{testPublicKey, "PCX"}}, "25213648ce237cc4a7e5649136d85426fb340d8d6c4431f5e02cc6b7d24fe22c"},
+	}
+	for _, test := range tests {
+		key, entry, err := m.StorageKey(test.module, test.item, test.keys...)
+		if err != nil {
+			t.Errorf("%s.%s: %v", test.module, test.item, err)
+			continue
+		}
+		if hex.EncodeToString(key) != test.expect {
+			t.Errorf("%s.%s key is %x,expect %s", test.module, test.item, key, test.expect)
+		}
+		if entry.Name != test.item {
+			t.Errorf("%s.%s entry is %s", test.module, test.item, entry.Name)
+		}
+	}
+}
+
+func TestStorageKeyV12(t *testing.T) {
+	m := loadFixture(t, "substrate_v12.hex")
+	tests := []struct {
+		module string
+		item   string
+		keys   []interface{}
+		expect string
+	}{
+		//twox128("System") + twox128("Events")
+		{"System", "Events", nil, "26aa394eea5630e07c48ae0c9558cef780d41e5e16056765bc8461851072c9d7"},
+		{"Timestamp", "Now", nil, "f0c365c3cf59d671eb72da0e7a4113c49f1f0515f462cdcf84e0f1d6045dfcbb"},
+		//Blake2_128Concat: blake2_128(公钥) + 公钥
+		{"System", "Account", []interface{}{testPublicKey},
+			"26aa394eea5630e07c48ae0c9558cef7b99d880ec681799c0cf30e8886371da9" +
+				"de1e86a9a8c739864cf3cc5ec2bea59fd43593c715fdd31c61141abd04a99fd6822c8558854ccde39a5684e7a56da27d"},
+		//DoubleMap: key2使用Twox64Concat
+		{"XAssets", "AssetBalance", []interface{}{testPublicKey, uint32(1)},
+			"74209c05d110bbda64087b1b63b42b9796013b1411bdd86021e124194d1515f7" +
+				"de1e86a9a8c739864cf3cc5ec2bea59fd43593c715fdd31c61141abd04a99fd6822c8558854ccde39a5684e7a56da27d" +
+				"5153cb1f00942ff401000000"},
+	}
+	for _, test := range tests {
+		key, _, err := m.StorageKey(test.module, test.item, test.keys...)
+		if err != nil {
+			t.Errorf("%s.%s: %v", test.module, test.item, err)
+			continue
+		}
+		if hex.EncodeToString(key) != test.expect {
+			t.Errorf("%s.%s key is %x,expect %s", test.module, test.item, key, test.expect)
+		}
+	}
+}
+
+func TestStorageKeyInvalid(t *testing.T) {
+	m := loadFixture(t, "substrate_v12.hex")
+	tests := map[string]func() error{
+		"unknown module": func() error { _, _, err := m.StorageKey("Staking", "Ledger"); return err },
+		"unknown item":   func() error { _, _, err := m.StorageKey("System", "AccountNonce", testPublicKey); return err },
+		"missing key":    func() error { _, _, err := m.StorageKey("System", "Account"); return err },
+		"extra key":      func() error { _, _, err := m.StorageKey("System", "Events", testPublicKey); return err },
+		"missing key2":   func() error { _, _, err := m.StorageKey("XAssets", "AssetBalance", testPublicKey); return err },
+		"invalid key":    func() error { _, _, err := m.StorageKey("System", "Account", "0x01"); return err },
+	}
+	for name, f := range tests {
+		if err := f(); err == nil {
+			t.Errorf("%s: expect error", name)
+		}
+	}
+	for _, item := range [][2]string{{"Staking", "Ledger"}, {"System", "AccountNonce"}} {
+		if _, err := m.FindStorage(item[0], item[1]); !errors.Is(err, ErrStorageNotFound) {
+			t.Errorf("find storage %s.%s error is %v", item[0], item[1], err)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"math/big"
+	"sync"
+)
+
+//ChainX中资产余额的类型，和运行时中的AssetType一致
+const (
+	AssetTypeFree                      = "Free"
+	AssetTypeReservedStaking           = "ReservedStaking"
+	AssetTypeReservedStakingRevocation = "ReservedStakingRevocation"
+	AssetTypeReservedWithdrawal        = "ReservedWithdrawal"
+	AssetTypeReservedDexSpot           = "ReservedDexSpot"
+	AssetTypeReservedDexFuture         = "ReservedDexFuture"
+)
+
+/*
+账户某个资产的余额
+Free为可用余额，ReservedStaking包括投票和赎回中的锁定，ReservedDex包括现货和期货挂单的锁定
+Details为按AssetType的原始数据
+*/
+type AssetBalance struct {
+	Token              string              `json:"token"`
+	Free               *big.Int            `json:"free"`
+	ReservedStaking    *big.Int            `json:"reserved_staking"`
+	ReservedDex        *big.Int            `json:"reserved_dex"`
+	ReservedWithdrawal *big.Int            `json:"reserved_withdrawal"`
+	Details            map[string]*big.Int `json:"details"`
+}
+
+func NewAssetBalance(token string, details map[string]*big.Int) *AssetBalance {
+	if details == nil {
+		details = make(map[string]*big.Int)
+	}
+	sum := func(types ...string) *big.Int {
+		total := new(big.Int)
+		for _, t := range types {
+			if v, ok := details[t]; ok && v != nil {
+				total.Add(total, v)
+			}
+		}
+		return total
+	}
+	return &AssetBalance{
+		Token:              token,
+		Free:               sum(AssetTypeFree),
+		ReservedStaking:    sum(AssetTypeReservedStaking, AssetTypeReservedStakingRevocation),
+		ReservedDex:        sum(AssetTypeReservedDexSpot, AssetTypeReservedDexFuture),
+		ReservedWithdrawal: sum(AssetTypeReservedWithdrawal),
+		Details:            details,
+	}
+}
+
+//所有类型余额的和
+func (b *AssetBalance) Total() *big.Int {
+	total := new(big.Int)
+	for _, v := range b.Details {
+		if v != nil {
+			total.Add(total, v)
+		}
+	}
+	return total
+}
+
+//chainx_getAssetsByAccount 返回的每个资产
+type AccountAsset struct {
+	Name     string              `json:"name"`
+	IsNative bool                `json:"isNative"`
+	Details  map[string]*big.Int `json:"details"`
+}
+
+//chainx_getAssetsByAccount 返回的分页数据
+type AccountAssetPage struct {
+	PageIndex int             `json:"pageIndex"`
+	PageSize  int             `json:"pageSize"`
+	PageTotal int             `json:"pageTotal"`
+	Data      []*AccountAsset `json:"data"`
+}
+
+//chainx_getAssets 返回的资产信息
+type AssetInfo struct {
+	Token     string `json:"name"`
+	TokenName string `json:"tokenName"`
+	Chain     string `json:"chain"`
+	Precision int32  `json:"precision"`
+	Desc      string `json:"desc"`
+	Online    bool   `json:"online"`
+}
+
+//把带小数的金额转换为链上的最小单位，小数位超过精度时返回错误
+func (a *AssetInfo) ToBaseUnits(amount decimal.Decimal) (uint64, error) {
+	if amount.IsNegative() {
+		return 0, fmt.Errorf("%s amount %s is negative", a.Token, amount)
+	}
+	units := amount.Shift(a.Precision)
+	if !units.Equal(units.Truncate(0)) {
+		return 0, fmt.Errorf("%s amount %s exceeds precision %d", a.Token, amount, a.Precision)
+	}
+	n := units.BigInt()
+	if !n.IsUint64() {
+		return 0, fmt.Errorf("%s amount %s overflows uint64", a.Token, amount)
+	}
+	return n.Uint64(), nil
+}
+
+//把链上的最小单位转换为带小数的金额
+func (a *AssetInfo) FromBaseUnits(units uint64) decimal.Decimal {
+	return decimal.NewFromBigInt(new(big.Int).SetUint64(units), -a.Precision)
+}
+
+var ErrUnknownToken = errors.New("unknown token")
+
+//AssetRegistry 按token保存资产信息，token区分大小写，和链上一致
+type AssetRegistry struct {
+	mu     sync.RWMutex
+	assets map[string]*AssetInfo
+	tokens []string
+}
+
+func NewAssetRegistry(assets []*AssetInfo) *AssetRegistry {
+	r := &AssetRegistry{assets: make(map[string]*AssetInfo)}
+	for _, asset := range assets {
+		r.Add(asset)
+	}
+	return r
+}
+
+func (r *AssetRegistry) Add(asset *AssetInfo) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.assets[asset.Token]; !ok {
+		r.tokens = append(r.tokens, asset.Token)
+	}
+	r.assets[asset.Token] = asset
+}
+
+//token不存在时返回ErrUnknownToken
+func (r *AssetRegistry) Get(token string) (*AssetInfo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	asset, ok := r.assets[token]
+	if !ok {
+		return nil, fmt.Errorf("%w %s", ErrUnknownToken, token)
+	}
+	return asset, nil
+}
+
+//按链上返回的顺序
+func (r *AssetRegistry) Tokens() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]string(nil), r.tokens...)
+}
+
+func (r *AssetRegistry) ToBaseUnits(token string, amount decimal.Decimal) (uint64, error) {
+	asset, err := r.Get(token)
+	if err != nil {
+		return 0, err
+	}
+	return asset.ToBaseUnits(amount)
+}
+
+func (r *AssetRegistry) FromBaseUnits(token string, units uint64) (decimal.Decimal, error) {
+	asset, err := r.Get(token)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return asset.FromBaseUnits(units), nil
+}
+
+//chainx_getAssets 返回的分页数据
+type AssetInfoPage struct {
+	PageIndex int          `json:"pageIndex"`
+	PageSize  int          `json:"pageSize"`
+	PageTotal int          `json:"pageTotal"`
+	Data      []*AssetInfo `json:"data"`
+}
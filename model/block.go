@@ -1,5 +1,7 @@
 package model
 
+import codec "github.com/JFJun/chainX-go/codes"
+
 type ChainXBlock struct {
 	Block         Block  `json:"block"`
 	Justification []byte `json:"justification"`
@@ -37,9 +39,19 @@ type ChainXExtrinsicResponse struct {
 	Signature      string `json:"signature"`
 	Nonce          int64  `json:"nonce"`
 	Era            string `json:"era"`
+	EraBirth       uint64 `json:"era_birth,omitempty"` //mortal交易开始有效的区块高度
+	EraDeath       uint64 `json:"era_death,omitempty"` //mortal交易失效的区块高度
 	ExtrinsicIndex int    `json:"extrinsic_index"`
 	Token          string `json:"token"`
 	Memo           string `json:"memo"`
+	//根据metadata解析出的调用，所有类型的交易都有
+	Module string             `json:"module"`
+	Call   string             `json:"call"`
+	Args   []codec.NamedValue `json:"args"`
+	//call index加上参数的原始数据，0x开头
+	CallData string `json:"call_data"`
+	//按metadata解析call失败时的错误，成功时为空
+	CallError string `json:"call_error,omitempty"`
 }
 
 type ChainXBlockEventResponse struct {
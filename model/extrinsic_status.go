@@ -0,0 +1,77 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+//author_submitAndWatchExtrinsic 推送的交易状态
+const (
+	ExtrinsicFuture          = "Future"    //nonce过大，等待前面的交易
+	ExtrinsicReady           = "Ready"     //进入交易池，等待打包
+	ExtrinsicBroadcast       = "Broadcast" //已经广播给其他节点
+	ExtrinsicInBlock         = "InBlock"   //已经被打包进区块
+	ExtrinsicRetracted       = "Retracted" //打包的区块被回滚
+	ExtrinsicFinalized       = "Finalized" //区块已经确认，旧版本节点打包进区块时也返回这个状态
+	ExtrinsicFinalityTimeout = "FinalityTimeout"
+	ExtrinsicUsurped         = "Usurped" //被相同nonce的交易替换
+	ExtrinsicDropped         = "Dropped" //被交易池丢弃
+	ExtrinsicInvalid         = "Invalid" //交易无效
+)
+
+var extrinsicStatusNames = map[string]string{
+	"future":          ExtrinsicFuture,
+	"ready":           ExtrinsicReady,
+	"broadcast":       ExtrinsicBroadcast,
+	"inBlock":         ExtrinsicInBlock,
+	"retracted":       ExtrinsicRetracted,
+	"finalized":       ExtrinsicFinalized,
+	"finalityTimeout": ExtrinsicFinalityTimeout,
+	"usurped":         ExtrinsicUsurped,
+	"dropped":         ExtrinsicDropped,
+	"invalid":         ExtrinsicInvalid,
+}
+
+type ExtrinsicStatus struct {
+	Type  string   `json:"type"`
+	Hash  string   `json:"hash,omitempty"`  //InBlock,Retracted,Finalized,FinalityTimeout为区块hash，Usurped为替换的交易hash
+	Peers []string `json:"peers,omitempty"` //Broadcast时广播的节点
+}
+
+//是否为最终状态，之后节点不会再推送
+func (es *ExtrinsicStatus) IsFinal() bool {
+	switch es.Type {
+	case ExtrinsicFinalized, ExtrinsicFinalityTimeout, ExtrinsicUsurped, ExtrinsicDropped, ExtrinsicInvalid:
+		return true
+	}
+	return false
+}
+
+//节点返回的格式为 "ready" 或者 {"inBlock":"0x..."}
+func (es *ExtrinsicStatus) UnmarshalJSON(b []byte) error {
+	var name string
+	if err := json.Unmarshal(b, &name); err == nil {
+		t, ok := extrinsicStatusNames[name]
+		if !ok {
+			return fmt.Errorf("unknown extrinsic status %s", name)
+		}
+		es.Type = t
+		return nil
+	}
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return err
+	}
+	for name, value := range obj {
+		t, ok := extrinsicStatusNames[name]
+		if !ok {
+			return fmt.Errorf("unknown extrinsic status %s", name)
+		}
+		es.Type = t
+		if t == ExtrinsicBroadcast {
+			return json.Unmarshal(value, &es.Peers)
+		}
+		return json.Unmarshal(value, &es.Hash)
+	}
+	return fmt.Errorf("invalid extrinsic status %s", string(b))
+}
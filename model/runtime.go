@@ -0,0 +1,18 @@
+package model
+
+//state_getRuntimeVersion 返回的运行版本
+type RuntimeVersion struct {
+	SpecName           string `json:"specName"`
+	ImplName           string `json:"implName"`
+	AuthoringVersion   uint32 `json:"authoringVersion"`
+	SpecVersion        uint32 `json:"specVersion"`
+	ImplVersion        uint32 `json:"implVersion"`
+	TransactionVersion uint32 `json:"transactionVersion"` //ChainX 1.0 中没有这个字段，为0
+}
+
+//system_properties 返回的链的属性，节点没有配置时使用ChainX的默认值
+type ChainProperties struct {
+	SS58Format    uint8  `json:"ss58Format"`
+	TokenDecimals int    `json:"tokenDecimals"`
+	TokenSymbol   string `json:"tokenSymbol"`
+}
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+//state_subscribeStorage 推送和state_queryStorage 返回的数据
+type StorageChangeSet struct {
+	Block   string          `json:"block"`
+	Changes []StorageChange `json:"changes"`
+}
+
+//Value 为空表示storage被删除
+type StorageChange struct {
+	Key   string
+	Value string
+}
+
+//节点返回的格式为 [key, value]
+func (sc *StorageChange) UnmarshalJSON(b []byte) error {
+	var kv []*string
+	if err := json.Unmarshal(b, &kv); err != nil {
+		return err
+	}
+	if len(kv) != 2 || kv[0] == nil {
+		return errors.New("invalid storage change")
+	}
+	sc.Key = *kv[0]
+	sc.Value = ""
+	if kv[1] != nil {
+		sc.Value = *kv[1]
+	}
+	return nil
+}
+
+func (sc StorageChange) MarshalJSON() ([]byte, error) {
+	if sc.Value == "" {
+		return json.Marshal([]interface{}{sc.Key, nil})
+	}
+	return json.Marshal([]string{sc.Key, sc.Value})
+}
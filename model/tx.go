@@ -19,6 +19,8 @@ type ChainXEventData struct {
 	Amount  int64
 }
 
+//解析chainx_getExtrinsicsEventsByBlockHash返回的事件字符串
+//Deprecated: 使用events.DecodeEventRecords解析System Events
 func ParseChainXEventData(data string) (*ChainXEventData, error) {
 	ced := new(ChainXEventData)
 	ced.RawData = data
@@ -0,0 +1,221 @@
+package rpc
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/JFJun/chainX-go/model"
+	"github.com/JFJun/chainX-go/ss58"
+	"github.com/JFJun/chainX-go/util"
+	"math/big"
+	"strings"
+)
+
+//ChainX的原生资产，可用余额保存在Balances中
+const nativeToken = "PCX"
+
+//分页接口每次请求的数量
+const assetPageSize = 100
+
+//通过chainx_getAssetsByAccount获取账户所有资产的余额
+func (client *Client) GetAssetsByAccount(address string) ([]*model.AssetBalance, error) {
+	return client.GetAssetsByAccountContext(context.Background(), address)
+}
+
+func (client *Client) GetAssetsByAccountContext(ctx context.Context, address string) ([]*model.AssetBalance, error) {
+	pub, err := ss58.DecodeToPub(address)
+	if err != nil {
+		return nil, err
+	}
+	who := "0x" + hex.EncodeToString(pub)
+	var balances []*model.AssetBalance
+	for page := 0; ; page++ {
+		respData, err := client.Rpc.CallContext(ctx, "chainx_getAssetsByAccount", []interface{}{who, page, assetPageSize})
+		if err != nil {
+			//没有任何资产的账户返回null
+			if errors.Is(err, util.ErrNullResult) {
+				return balances, nil
+			}
+			return nil, fmt.Errorf("get assets by account error,err=%w", err)
+		}
+		var assets model.AccountAssetPage
+		if err := json.Unmarshal(respData, &assets); err != nil {
+			return nil, fmt.Errorf("parse assets by account error,err=%v", err)
+		}
+		for _, asset := range assets.Data {
+			balances = append(balances, model.NewAssetBalance(asset.Name, asset.Details))
+		}
+		if len(assets.Data) == 0 || page+1 >= assets.PageTotal {
+			return balances, nil
+		}
+	}
+}
+
+/*
+通过XAssets AssetBalance storage获取账户某个资产的余额
+PCX的可用余额在Balances FreeBalance中
+*/
+func (client *Client) GetAssetBalance(address, token string) (*model.AssetBalance, error) {
+	return client.GetAssetBalanceContext(context.Background(), address, token)
+}
+
+func (client *Client) GetAssetBalanceContext(ctx context.Context, address, token string) (*model.AssetBalance, error) {
+	return client.GetAssetBalanceAtContext(ctx, address, token, "")
+}
+
+//blockHash时账户某个资产的余额
+func (client *Client) GetAssetBalanceAt(address, token, blockHash string) (*model.AssetBalance, error) {
+	return client.GetAssetBalanceAtContext(context.Background(), address, token, blockHash)
+}
+
+func (client *Client) GetAssetBalanceAtContext(ctx context.Context, address, token, blockHash string) (*model.AssetBalance, error) {
+	pub, err := ss58.DecodeToPub(address)
+	if err != nil {
+		return nil, err
+	}
+	//余额和FreeBalance使用blockHash时的metadata查询
+	meta, err := client.loadMetadata(ctx, blockHash)
+	if err != nil {
+		return nil, err
+	}
+	v, err := client.queryStorage(ctx, meta, blockHash, "XAssets", "AssetBalance", []interface{}{pub, token})
+	if err != nil {
+		return nil, err
+	}
+	details, err := parseAssetDetails(v)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s balance error,err=%v", token, err)
+	}
+	if strings.EqualFold(token, nativeToken) {
+		if _, err := meta.FindStorage("Balances", "FreeBalance"); err == nil {
+			free, err := client.queryStorage(ctx, meta, blockHash, "Balances", "FreeBalance", pub)
+			if err != nil {
+				return nil, err
+			}
+			details[model.AssetTypeFree], err = toBigInt(free)
+			if err != nil {
+				return nil, fmt.Errorf("parse %s free balance error,err=%v", token, err)
+			}
+		}
+	}
+	return model.NewAssetBalance(token, details), nil
+}
+
+//AssetBalance的值为CodecBTreeMap<AssetType, Balance>，解析后为[AssetType, Balance]的列表
+func parseAssetDetails(v interface{}) (map[string]*big.Int, error) {
+	details := make(map[string]*big.Int)
+	if v == nil {
+		return details, nil
+	}
+	items, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("asset balance is %T not list", v)
+	}
+	for _, item := range items {
+		kv, ok := item.([]interface{})
+		if !ok || len(kv) != 2 {
+			return nil, fmt.Errorf("invalid asset balance item %v", item)
+		}
+		assetType, ok := kv[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("asset type is %T not string", kv[0])
+		}
+		balance, err := toBigInt(kv[1])
+		if err != nil {
+			return nil, err
+		}
+		details[assetType] = balance
+	}
+	return details, nil
+}
+
+func toBigInt(v interface{}) (*big.Int, error) {
+	switch n := v.(type) {
+	case nil:
+		return new(big.Int), nil
+	case uint64:
+		return new(big.Int).SetUint64(n), nil
+	case *big.Int:
+		return new(big.Int).Set(n), nil
+	}
+	return nil, fmt.Errorf("balance is %T not integer", v)
+}
+
+//通过chainx_getAssets获取链上所有的资产
+func (client *Client) GetAssets() ([]*model.AssetInfo, error) {
+	return client.GetAssetsContext(context.Background())
+}
+
+func (client *Client) GetAssetsContext(ctx context.Context) ([]*model.AssetInfo, error) {
+	var assets []*model.AssetInfo
+	for page := 0; ; page++ {
+		respData, err := client.Rpc.CallContext(ctx, "chainx_getAssets", []interface{}{page, assetPageSize})
+		if err != nil {
+			return nil, fmt.Errorf("get assets error,err=%w", err)
+		}
+		var infos model.AssetInfoPage
+		if err := json.Unmarshal(respData, &infos); err != nil {
+			return nil, fmt.Errorf("parse assets error,err=%v", err)
+		}
+		assets = append(assets, infos.Data...)
+		if len(infos.Data) == 0 || page+1 >= infos.PageTotal {
+			return assets, nil
+		}
+	}
+}
+
+//资产的精度等信息，第一次使用时通过GetAssets获取
+func (client *Client) GetAssetRegistry() (*model.AssetRegistry, error) {
+	return client.GetAssetRegistryContext(context.Background())
+}
+
+func (client *Client) GetAssetRegistryContext(ctx context.Context) (*model.AssetRegistry, error) {
+	client.mu.Lock()
+	registry := client.assets
+	client.mu.Unlock()
+	if registry != nil {
+		return registry, nil
+	}
+	return client.RefreshAssetRegistryContext(ctx)
+}
+
+//重新获取资产信息，例如有新注册的资产
+func (client *Client) RefreshAssetRegistry() (*model.AssetRegistry, error) {
+	return client.RefreshAssetRegistryContext(context.Background())
+}
+
+func (client *Client) RefreshAssetRegistryContext(ctx context.Context) (*model.AssetRegistry, error) {
+	assets, err := client.GetAssetsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	registry := model.NewAssetRegistry(assets)
+	client.mu.Lock()
+	client.assets = registry
+	client.mu.Unlock()
+	return registry, nil
+}
+
+/*
+检查资产是否存在，缓存中没有时重新获取一次资产信息，资产可能是在缓存之后注册的
+节点不支持chainx_getAssets时不检查，由节点验证交易
+*/
+func (client *Client) checkToken(ctx context.Context, token string) error {
+	registry, err := client.GetAssetRegistryContext(ctx)
+	if util.IsMethodNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := registry.Get(token); err == nil {
+		return nil
+	}
+	if registry, err = client.RefreshAssetRegistryContext(ctx); err != nil {
+		return err
+	}
+	_, err = registry.Get(token)
+	return err
+}
@@ -0,0 +1,67 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/JFJun/chainX-go/model"
+)
+
+type ExtrinsicStatusSubscription struct {
+	subscription
+	statuses chan *model.ExtrinsicStatus
+}
+
+//交易状态的变化，收到最终状态或者订阅出错后被关闭
+func (s *ExtrinsicStatusSubscription) Statuses() <-chan *model.ExtrinsicStatus {
+	return s.statuses
+}
+
+//提交签名后的交易，extrinsic为CombineChainXtx返回的hex，返回交易hash
+func (client *Client) SubmitExtrinsic(extrinsic string) (string, error) {
+	return client.SubmitExtrinsicContext(context.Background(), extrinsic)
+}
+
+func (client *Client) SubmitExtrinsicContext(ctx context.Context, extrinsic string) (string, error) {
+	respData, err := client.Rpc.CallContext(ctx, "author_submitExtrinsic", []interface{}{extrinsic})
+	if err != nil {
+		return "", fmt.Errorf("submit extrinsic error,err=%w", err)
+	}
+	if len(respData) == 0 {
+		return "", errors.New("submit extrinsic error,tx hash is empty")
+	}
+	return string(respData), nil
+}
+
+//提交签名后的交易，并订阅交易状态的变化
+func (client *Client) SubmitAndWatch(extrinsic string) (*ExtrinsicStatusSubscription, error) {
+	return client.SubmitAndWatchContext(context.Background(), extrinsic)
+}
+
+func (client *Client) SubmitAndWatchContext(ctx context.Context, extrinsic string) (*ExtrinsicStatusSubscription, error) {
+	sub, err := client.subscribe(ctx, "author_submitAndWatchExtrinsic", "author_unwatchExtrinsic", []interface{}{extrinsic})
+	if err != nil {
+		return nil, err
+	}
+	ss := &ExtrinsicStatusSubscription{
+		subscription: subscription{sub: sub, err: make(chan error, 1)},
+		statuses:     make(chan *model.ExtrinsicStatus),
+	}
+	go ss.run(func(raw json.RawMessage) error {
+		status := new(model.ExtrinsicStatus)
+		if err := json.Unmarshal(raw, status); err != nil {
+			return fmt.Errorf("parse extrinsic status error,err=%v", err)
+		}
+		select {
+		case ss.statuses <- status:
+		case <-sub.Done():
+			return nil
+		}
+		if status.IsFinal() {
+			return errSubscriptionDone
+		}
+		return nil
+	}, func() { close(ss.statuses) })
+	return ss, nil
+}
@@ -0,0 +1,172 @@
+package rpc
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	codec "github.com/JFJun/chainX-go/codes"
+	"github.com/JFJun/chainX-go/model"
+	"github.com/JFJun/chainX-go/ss58"
+	"github.com/JFJun/chainX-go/tx"
+	"github.com/JFJun/chainX-go/util"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+const (
+	testSeed = "0x0101010101010101010101010101010101010101010101010101010101010101"
+	//V6的metadata，版本不支持
+	testMetadataV6 = "0x6d6574610600"
+)
+
+func testFixture(t *testing.T, name string) string {
+	data, err := ioutil.ReadFile("../metadata/testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func testBlockHash(height int) string {
+	return fmt.Sprintf("0x%064x", height)
+}
+
+//已签名的交易，call为callIndex加上参数
+func testExtrinsic(t *testing.T, callData []byte, nonce uint64) string {
+	call, err := tx.NewRawCall(callData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := tx.NewEd25519Signer(testSeed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	extrinsic, err := tx.NewExtrinsicBuilder(call, nonce, testBlockHash(0)).SignWith(signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return extrinsic
+}
+
+func testTimestampExtrinsic(t *testing.T, now uint64) string {
+	call, err := tx.NewRawCall(append([]byte{0x01, 0x00}, codec.CompactBytes(now)...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	extrinsic, err := tx.NewExtrinsicBuilder(call, 0, "").Unsigned()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return extrinsic
+}
+
+func testTransferCall(t *testing.T, callId string) []byte {
+	method, err := tx.NewChainXMethodTransfer(hex.EncodeToString(make([]byte, 32)), "PCX", "memo", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return method.Encode(callId)
+}
+
+func addBlock(t *testing.T, mock *util.MockTransport, height int, specVersion uint32, extrinsics ...string) {
+	blockHash := testBlockHash(height)
+	mock.Add("chain_getBlockHash", []interface{}{height}, blockHash)
+	block := model.ChainXBlock{Block: model.Block{
+		Header:     model.Header{Number: fmt.Sprintf("0x%x", height), ParentHash: testBlockHash(height - 1)},
+		Extrinsics: extrinsics,
+	}}
+	mock.Add("chain_getBlock", []interface{}{blockHash}, block)
+	mock.Add("state_getRuntimeVersion", []interface{}{blockHash}, model.RuntimeVersion{SpecName: "chainx", SpecVersion: specVersion})
+}
+
+func TestGetBlockByHashLegacy(t *testing.T) {
+	mock := util.NewMockTransport()
+	addBlock(t, mock, 1, 1, testTimestampExtrinsic(t, 1580000000), testExtrinsic(t, testTransferCall(t, tx.CallIdTransfer), 5))
+	mock.Add("state_getMetadata", []interface{}{testBlockHash(1)}, testMetadataV6)
+	client := NewWithTransport(mock)
+	//metadata版本不支持时按固定的call id解析，不解析事件
+	block, err := client.GetBlockByHash(testBlockHash(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if block.Height != 1 || block.Timestamp != 1580000000 || len(block.Extrinsic) != 2 {
+		t.Fatalf("block is %+v", block)
+	}
+	ex := block.Extrinsic[1]
+	to, _ := ss58.Encode(make([]byte, 32), ss58.ChainXPrefix)
+	if ex.Module != "XAssets" || ex.Call != "transfer" || ex.Type != "transfer" || ex.ToAddress != to ||
+		ex.Token != "PCX" || ex.Amount != "100" || ex.Memo != "memo" || ex.Nonce != 5 || ex.CallError != "" {
+		t.Fatalf("transfer is %+v", ex)
+	}
+	if block.Extrinsic[0].Module != "Timestamp" || block.Extrinsic[0].Call != "set" {
+		t.Fatalf("timestamp is %+v", block.Extrinsic[0])
+	}
+
+	blocks, err := client.GetBlocksByRange(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blocks[0].Timestamp != 1580000000 || blocks[0].Extrinsic[1].Amount != "100" || blocks[0].Extrinsic[1].Fee != "" {
+		t.Fatalf("batch block is %+v", blocks[0])
+	}
+
+	//其他错误直接返回
+	mock.AddError("state_getMetadata", []interface{}{testBlockHash(1)}, errors.New("connection refused"))
+	if _, err := client.GetBlockByHash(testBlockHash(1)); err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Fatalf("get block error is %v", err)
+	}
+	if _, err := client.GetBlocksByRange(1, 1); err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Fatalf("get blocks error is %v", err)
+	}
+}
+
+func TestGetBlocksByRangeRuntimeUpgrade(t *testing.T) {
+	mock := util.NewMockTransport()
+	//区块1的spec version为1，使用ChainX 1.0的metadata，XAssets.transfer为0803
+	addBlock(t, mock, 1, 1, testExtrinsic(t, testTransferCall(t, "0803"), 0))
+	//区块2和3运行时升级到spec version 2，XAssets.transfer为0800，0803不存在
+	upgraded := append([]byte{0x08, 0x00}, 0xff)
+	upgraded = append(upgraded, make([]byte, 32)...)
+	upgraded = append(upgraded, 1<<2, 10<<2)
+	addBlock(t, mock, 2, 2, testExtrinsic(t, upgraded, 1))
+	addBlock(t, mock, 3, 2)
+	mock.Add("state_getMetadata", []interface{}{testBlockHash(1)}, testFixture(t, "chainx_v7.hex"))
+	mock.Add("state_getMetadata", []interface{}{testBlockHash(2)}, testFixture(t, "substrate_v12.hex"))
+
+	//区块1: ApplyExtrinsic(0) System.ExtrinsicSuccess，XFeeManager.FeeForProducer 10
+	events := []byte{2 << 2, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
+	events = append(events, 0x00, 0x00, 0x00, 0x00, 0x00, 0x04, 0x01)
+	events = append(events, make([]byte, 32)...)
+	events = append(events, 10, 0, 0, 0, 0, 0, 0, 0)
+	mock.Add("state_getStorage", []interface{}{"0xcc956bdb7605e3547539f321ac2bc95c", testBlockHash(1)}, "0x"+hex.EncodeToString(events))
+	//区块2: 新的System.Events key，没有事件
+	mock.AddError("state_getStorage", []interface{}{"0x26aa394eea5630e07c48ae0c9558cef780d41e5e16056765bc8461851072c9d7", testBlockHash(2)}, util.ErrNullResult)
+
+	client := NewWithTransport(mock)
+	blocks, err := client.GetBlocksByRange(1, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != 3 || blocks[0].Height != 1 || blocks[1].Height != 2 || blocks[2].Height != 3 {
+		t.Fatalf("blocks are %+v", blocks)
+	}
+	ex := blocks[0].Extrinsic[0]
+	if ex.Module != "XAssets" || ex.Call != "transfer" || ex.Type != "transfer" || ex.Token != "PCX" || ex.Amount != "100" {
+		t.Fatalf("block 1 extrinsic is %+v", ex)
+	}
+	if ex.Status != eventStatusSuccess || ex.Fee != "10" {
+		t.Fatalf("block 1 extrinsic status %s fee %s", ex.Status, ex.Fee)
+	}
+	//升级后的区块使用新的metadata解析
+	ex = blocks[1].Extrinsic[0]
+	if ex.Module != "XAssets" || ex.Call != "transfer" || ex.CallError != "" || len(ex.Args) != 3 {
+		t.Fatalf("block 2 extrinsic is %+v", ex)
+	}
+	if ex.Status != "" || ex.Fee != "0" {
+		t.Fatalf("block 2 extrinsic status %s fee %s", ex.Status, ex.Fee)
+	}
+	if len(blocks[2].Extrinsic) != 0 {
+		t.Fatalf("block 3 extrinsics are %+v", blocks[2].Extrinsic)
+	}
+}
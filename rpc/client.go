@@ -1,185 +1,525 @@
 package rpc
 
 import (
+	"context"
 	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/JFJun/chainX-go/events"
+	"github.com/JFJun/chainX-go/metadata"
 	"github.com/JFJun/chainX-go/model"
 	"github.com/JFJun/chainX-go/ss58"
 	"github.com/JFJun/chainX-go/tx"
 	"github.com/JFJun/chainX-go/util"
-	"github.com/JFJun/chainX-go/xxhash"
 	"golang.org/x/crypto/blake2b"
+	"net/url"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 type Client struct {
-	Rpc         *util.RpcClient
-	CoinType    string
-	GenesisHash string
+	Rpc util.Transport
+
+	mu                 sync.Mutex
+	coinType           string //以下字段通过同名的方法获取
+	genesisHash        string
+	ss58Prefix         uint8
+	tokenDecimals      int
+	specVersion        uint32 //最新的运行版本，通过RuntimeVersion获取
+	transactionVersion uint32
+	metas              map[uint32]*metadata.Metadata //按运行时的spec version缓存
+	assets             *model.AssetRegistry
+	runtimeSub         *util.Subscription //WithRuntimeWatch的订阅，重新订阅后会变化
+	closing            chan struct{}      //Close时关闭，通知订阅运行版本的goroutine退出
 }
 
-func New(url, user, password string) (*Client, error) {
+/*
+根据url选择Transport，scheme不区分大小写
+ws://,wss:// 使用websocket，http://,https:// 使用http，ipc://或者绝对路径作为unix socket路径使用ipc
+其他的scheme返回错误
+opts可以设置初始化时获取运行版本，例如 New(url, "", "", WithRuntimeWatch())
+也可以设置超时和重试，例如 New(url, "", "", WithTimeout(10*time.Second), WithRetry(util.RetryConfig{MaxRetries: 3}))
+*/
+func New(rawUrl, user, password string, opts ...Option) (*Client, error) {
+	transport, err := newTransport(rawUrl, user, password)
+	if err != nil {
+		return nil, err
+	}
 	client := new(Client)
-	if strings.HasPrefix(url, "wss") {
-		//todo 连接websocket
-		return client, errors.New("do not support websocket")
+	client.Rpc = transport
+	o := new(options)
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.timeout != nil {
+		if t, ok := transport.(interface{ SetTimeout(time.Duration) }); ok {
+			t.SetTimeout(*o.timeout)
+		}
+	}
+	if o.retry != nil {
+		if t, ok := transport.(*util.RpcClient); ok {
+			t.SetRetry(*o.retry)
+		}
 	}
-	client.Rpc = util.New(url, user, password)
-
 	//初始化运行版本
-
+	if o.initRuntime {
+		if err := client.InitRuntime(context.Background()); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+	//http连接不支持订阅，获取最新的metadata时检查运行时升级，也可以调用RefreshRuntime
+	if o.watchRuntime {
+		if err := client.watchRuntime(); err != nil && !errors.Is(err, util.ErrNotSupportSubscribe) {
+			client.Close()
+			return nil, err
+		}
+	}
 	return client, nil
 }
 
+//设置每次调用的超时时间，ctx中已经有deadline时不生效，0表示不设置超时
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = &timeout
+	}
+}
+
+/*
+设置http请求在网络错误和5xx时的重试策略，默认不重试
+websocket和ipc连接断开后会自动重连，不使用这个设置
+*/
+func WithRetry(retry util.RetryConfig) Option {
+	return func(o *options) {
+		o.retry = &retry
+	}
+}
+
+func newTransport(rawUrl, user, password string) (util.Transport, error) {
+	if filepath.IsAbs(rawUrl) {
+		return util.NewIpcClient(rawUrl), nil
+	}
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return nil, fmt.Errorf("parse url error,err=%v", err)
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "ws", "wss":
+		return util.NewWsClient(rawUrl, user, password), nil
+	case "http", "https":
+		return util.New(rawUrl, user, password), nil
+	case "ipc":
+		//ipc:///tmp/chainx.ipc
+		path := u.Host + u.Path
+		if path == "" {
+			return nil, fmt.Errorf("ipc url %s has no path", rawUrl)
+		}
+		return util.NewIpcClient(path), nil
+	}
+	return nil, fmt.Errorf("url scheme %q is not support", u.Scheme)
+}
+
+//使用自定义的Transport，例如测试时使用util.MockTransport
+func NewWithTransport(transport util.Transport) *Client {
+	client := new(Client)
+	client.Rpc = transport
+	return client
+}
+
+//关闭连接，并结束运行版本的订阅
+func (client *Client) Close() error {
+	client.mu.Lock()
+	if client.closing != nil {
+		close(client.closing)
+		client.closing = nil
+	}
+	sub := client.runtimeSub
+	client.runtimeSub = nil
+	client.mu.Unlock()
+	if sub != nil {
+		sub.Unsubscribe()
+	}
+	return client.Rpc.Close()
+}
+
 func (client *Client) GetBlockByNumber(height int64) (*model.ChainXBlockResponse, error) {
+	return client.GetBlockByNumberContext(context.Background(), height)
+}
+
+func (client *Client) GetBlockByNumberContext(ctx context.Context, height int64) (*model.ChainXBlockResponse, error) {
 	var (
 		respData []byte
 		err      error
 	)
-	respData, err = client.Rpc.SendRequest("chain_getBlockHash", []interface{}{height})
-	if err != nil || len(respData) == 0 {
-		return nil, fmt.Errorf("get block hash error,err=%v", err)
+	respData, err = client.Rpc.CallContext(ctx, "chain_getBlockHash", []interface{}{height})
+	if err != nil {
+		return nil, fmt.Errorf("get block hash error,err=%w", err)
+	}
+	if len(respData) == 0 {
+		return nil, errors.New("get block hash error,block hash is empty")
 	}
 	blockHash := string(respData)
-	return client.GetBlockByHash(blockHash)
+	return client.GetBlockByHashContext(ctx, blockHash)
 }
 
 func (client *Client) GetBlockByHash(blockHash string) (*model.ChainXBlockResponse, error) {
+	return client.GetBlockByHashContext(context.Background(), blockHash)
+}
+
+func (client *Client) GetBlockByHashContext(ctx context.Context, blockHash string) (*model.ChainXBlockResponse, error) {
 	var (
 		respData []byte
 		err      error
 	)
-	respData, err = client.Rpc.SendRequest("chain_getBlock", []interface{}{blockHash})
-	if err != nil || len(respData) == 0 {
-		return nil, fmt.Errorf("get block error,err=%v", err)
+	respData, err = client.Rpc.CallContext(ctx, "chain_getBlock", []interface{}{blockHash})
+	if err != nil {
+		return nil, fmt.Errorf("get block error,err=%w", err)
+	}
+	if len(respData) == 0 {
+		return nil, errors.New("get block error,block is empty")
+	}
+	//没有可用的metadata时按固定的call id解析，不解析事件
+	meta, err := client.loadMetadata(ctx, blockHash)
+	if err != nil && !isMetadataUnavailable(err) {
+		return nil, err
+	}
+	blockResp, hasExtrinsic, err := client.parseBlock(meta, blockHash, respData)
+	if err != nil {
+		return blockResp, err
+	}
+	if hasExtrinsic && meta != nil {
+		//解析事件event
+		records, err := client.getEvents(ctx, meta, blockHash)
+		if err == nil {
+			err = client.parseTxEvent(blockResp, records)
+		}
+		if err != nil {
+			return blockResp, fmt.Errorf("parse block event error,Err=%w", err)
+		}
+	}
+	return blockResp, nil
+}
+
+//GetBlocksByRange每次批量请求的区块数量
+const blockBatchSize = 100
+
+/*
+批量获取[from,to]区间的区块，使用批量请求减少网络往返
+每blockBatchSize个区块一批，依次批量获取区块hash，区块和运行版本，事件
+*/
+func (client *Client) GetBlocksByRange(from, to int64) ([]*model.ChainXBlockResponse, error) {
+	return client.GetBlocksByRangeContext(context.Background(), from, to)
+}
+
+func (client *Client) GetBlocksByRangeContext(ctx context.Context, from, to int64) ([]*model.ChainXBlockResponse, error) {
+	if from > to {
+		return nil, fmt.Errorf("invalid block range,from=%d,to=%d", from, to)
+	}
+	var blocks []*model.ChainXBlockResponse
+	for start := from; start <= to; start += blockBatchSize {
+		end := start + blockBatchSize - 1
+		if end > to {
+			end = to
+		}
+		batch, err := client.getBlocksBatch(ctx, start, end)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, batch...)
+	}
+	return blocks, nil
+}
+
+func (client *Client) getBlocksBatch(ctx context.Context, from, to int64) ([]*model.ChainXBlockResponse, error) {
+	var requests []util.Request
+	for height := from; height <= to; height++ {
+		requests = append(requests, util.Request{Method: "chain_getBlockHash", Params: []interface{}{height}})
+	}
+	hashResults, err := client.Rpc.BatchCallContext(ctx, requests)
+	if err != nil {
+		return nil, fmt.Errorf("batch get block hash error,err=%w", err)
+	}
+	requests = requests[:0]
+	for i, result := range hashResults {
+		if result.Error != nil {
+			return nil, fmt.Errorf("get block hash error,height=%d,err=%w", from+int64(i), result.Error)
+		}
+		if len(result.Result) == 0 {
+			return nil, fmt.Errorf("get block hash error,height=%d,block hash is empty", from+int64(i))
+		}
+		//区块和区块的运行版本一起获取，运行时升级前后的区块使用不同的metadata解析
+		blockHash := string(result.Result)
+		requests = append(requests,
+			util.Request{Method: "chain_getBlock", Params: []interface{}{blockHash}},
+			util.Request{Method: "state_getRuntimeVersion", Params: []interface{}{blockHash}})
+	}
+	blockResults, err := client.Rpc.BatchCallContext(ctx, requests)
+	if err != nil {
+		return nil, fmt.Errorf("batch get block error,err=%w", err)
+	}
+	blocks := make([]*model.ChainXBlockResponse, len(hashResults))
+	var (
+		eventRequests []util.Request
+		eventBlocks   []*model.ChainXBlockResponse
+		eventMetas    []*metadata.Metadata
+	)
+	for i := range hashResults {
+		blockHash := string(hashResults[i].Result)
+		result, versionResult := blockResults[2*i], blockResults[2*i+1]
+		if result.Error != nil {
+			return nil, fmt.Errorf("get block error,blockHash=%s,err=%w", blockHash, result.Error)
+		}
+		if len(result.Result) == 0 {
+			return nil, fmt.Errorf("get block error,blockHash=%s,block is empty", blockHash)
+		}
+		meta, err := client.blockMetadata(ctx, blockHash, versionResult)
+		if err != nil {
+			return nil, err
+		}
+		blockResp, hasExtrinsic, err := client.parseBlock(meta, blockHash, result.Result)
+		if err != nil {
+			return nil, err
+		}
+		blocks[i] = blockResp
+		if hasExtrinsic && meta != nil {
+			eventsKey, err := eventsStorageKey(meta)
+			if err != nil {
+				return nil, err
+			}
+			eventRequests = append(eventRequests, util.Request{Method: "state_getStorage", Params: []interface{}{eventsKey, blockHash}})
+			eventBlocks = append(eventBlocks, blockResp)
+			eventMetas = append(eventMetas, meta)
+		}
+	}
+	if len(eventRequests) == 0 {
+		return blocks, nil
+	}
+	eventResults, err := client.Rpc.BatchCallContext(ctx, eventRequests)
+	if err != nil {
+		return nil, fmt.Errorf("batch get block event error,err=%w", err)
+	}
+	for i, result := range eventResults {
+		blockHash := eventBlocks[i].BlockHash
+		var records []*events.EventRecord
+		switch {
+		case errors.Is(result.Error, util.ErrNullResult):
+			//区块中没有事件时storage不存在
+		case result.Error != nil:
+			return nil, fmt.Errorf("get blockhash=[%s] event error,err=%w", blockHash, result.Error)
+		case len(result.Result) == 0:
+			return nil, fmt.Errorf("get blockhash=[%s] event error,event is empty", blockHash)
+		default:
+			if records, err = events.DecodeEventRecordsHex(eventMetas[i], string(result.Result)); err != nil {
+				return nil, fmt.Errorf("parse block event error,Err=%v", err)
+			}
+		}
+		if err := client.parseTxEvent(eventBlocks[i], records); err != nil {
+			return nil, fmt.Errorf("parse block event error,Err=%v", err)
+		}
+	}
+	return blocks, nil
+}
+
+//批量请求中区块的运行版本对应的metadata，没有可用的metadata时返回nil
+func (client *Client) blockMetadata(ctx context.Context, blockHash string, versionResult util.BatchResult) (*metadata.Metadata, error) {
+	if versionResult.Error != nil {
+		if isMetadataUnavailable(versionResult.Error) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get runtime version error,blockHash=%s,err=%w", blockHash, versionResult.Error)
+	}
+	version := new(model.RuntimeVersion)
+	if err := json.Unmarshal(versionResult.Result, version); err != nil {
+		return nil, fmt.Errorf("parse runtime version error,blockHash=%s,err=%v", blockHash, err)
+	}
+	meta, err := client.metadataOf(ctx, version.SpecVersion, blockHash)
+	if err != nil && !isMetadataUnavailable(err) {
+		return nil, err
 	}
+	return meta, nil
+}
+
+/*
+解析chain_getBlock返回的区块，hasExtrinsic表示是否需要继续解析事件
+meta为nil时按ChainX 1.0固定的call id解析转账和时间戳
+*/
+func (client *Client) parseBlock(meta *metadata.Metadata, blockHash string, respData []byte) (*model.ChainXBlockResponse, bool, error) {
 	var block model.ChainXBlock
-	err = json.Unmarshal(respData, &block)
+	err := json.Unmarshal(respData, &block)
 	if err != nil {
-		return nil, fmt.Errorf("parse block error")
+		return nil, false, fmt.Errorf("parse block error")
 	}
 	blockResp := new(model.ChainXBlockResponse)
 	number, _ := strconv.ParseInt(util.RemoveHex0x(block.Block.Header.Number), 16, 64)
 	blockResp.Height = number
 	blockResp.ParentHash = block.Block.Header.ParentHash
 	blockResp.BlockHash = blockHash
-	if len(block.Block.Extrinsics) > 0 { //todo parse extrinsic
-		err = client.parseBlockByExtrinsic(block.Block.Extrinsics, blockResp)
-		if err != nil {
-			return blockResp, fmt.Errorf("parse block extrinsic error,Err=[%v]", err)
-		}
-		//解析事件event
-		err = client.parseTxEventByBlockHash(blockResp)
-		if err != nil {
-			return blockResp, fmt.Errorf("parse block event error,Err=%v", err)
-		}
+	if len(block.Block.Extrinsics) == 0 {
+		return blockResp, false, nil
 	}
-	return blockResp, nil
+	err = client.parseBlockByExtrinsic(meta, block.Block.Extrinsics, blockResp)
+	if err != nil {
+		return blockResp, false, fmt.Errorf("parse block extrinsic error,Err=[%v]", err)
+	}
+	return blockResp, true, nil
 }
 
-func (client *Client) parseBlockByExtrinsic(extrinsics []string, blockResponse *model.ChainXBlockResponse) error {
+func (client *Client) parseBlockByExtrinsic(meta *metadata.Metadata, extrinsics []string, blockResponse *model.ChainXBlockResponse) error {
 	for i, extrinsic := range extrinsics {
 		if extrinsic == "" {
 			return errors.New("extrinsic is null")
 		}
-		data, err := hex.DecodeString(util.RemoveHex0x(extrinsic))
+		ex, err := tx.DecodeExtrinsicHex(extrinsic)
 		if err != nil {
-			return fmt.Errorf("hex decode extrinsic error,Err=%v", err)
+			return fmt.Errorf("parse extrinsic %d error,Err=%v", i, err)
 		}
-
-		ex := tx.NewChainXExtrinsic(data)
-		err = ex.ParseChainXExtrinsic()
-		if err != nil {
-			return fmt.Errorf("parse extrinsic error,Err=%v", err)
-		}
-		if ex.CallIndex == tx.CallIdTimestamp {
-			blockResponse.Timestamp = ex.Timestamp
-		} else if ex.CallIndex == tx.CallIdTransfer {
-			blockEx := new(model.ChainXExtrinsicResponse)
-			blockEx.Type = "transfer"
-			blockEx.FromAddress = ex.From
-			blockEx.ToAddress = ex.To
-			blockEx.Token = ex.Token
-			blockEx.Memo = ex.Memo
+		blockEx := new(model.ChainXExtrinsicResponse)
+		if ex.Signed {
+			blockEx.FromAddress = ex.Signer.String()
 			blockEx.Signature = ex.Signature
 			blockEx.Nonce = int64(ex.Nonce)
-			blockEx.Era = ex.Era
-			blockEx.ExtrinsicIndex = i
-			blockEx.Amount = fmt.Sprintf("%d", ex.Amount)
-			blockEx.Txid = client.createTxHash(extrinsic)
+			blockEx.Era = ex.Era.Hex()
+			if ex.Era.IsMortal {
+				blockEx.EraBirth = ex.Era.Birth(uint64(blockResponse.Height))
+				blockEx.EraDeath = ex.Era.Death(uint64(blockResponse.Height))
+			}
+		}
+		blockEx.ExtrinsicIndex = i
+		blockEx.Txid = client.createTxHash(extrinsic)
+		blockEx.CallData = ex.CallHex()
+		if meta == nil {
+			parseLegacyCall(blockResponse, blockEx, extrinsic)
+			blockResponse.Extrinsic = append(blockResponse.Extrinsic, blockEx)
+			continue
+		}
+		//call解析失败时错误保存在CallError中，CallData保留原始数据
+		//metadata中找不到的call没有module和call的名字，参数类型不支持时只有module和call的名字
+		if err := ex.DecodeCall(meta); err != nil {
+			blockEx.CallError = err.Error()
+		}
+		if ex.Call == nil {
 			blockResponse.Extrinsic = append(blockResponse.Extrinsic, blockEx)
+			continue
+		}
+		blockEx.Module = ex.Call.Module
+		blockEx.Call = ex.Call.Call
+		blockEx.Args = ex.Call.Args
+		blockEx.Type = ex.Call.Call
+		if blockEx.CallError == "" {
+			parseCallInfo(blockResponse, blockEx, ex.Call)
 		}
+		blockResponse.Extrinsic = append(blockResponse.Extrinsic, blockEx)
 	}
 	return nil
 }
 
-func (client *Client) parseTxEventByBlockHash(blockResponse *model.ChainXBlockResponse) error {
-	if blockResponse == nil {
-		return errors.New("block is null ptr")
+/*
+根据call设置区块时间和转账信息，按metadata中的名字判断，不依赖固定的call id
+Timestamp.set设置区块时间，XAssets.transfer设置交易类型为transfer和转账的信息
+*/
+func parseCallInfo(blockResponse *model.ChainXBlockResponse, blockEx *model.ChainXExtrinsicResponse, call *tx.DecodedCall) {
+	args := call.Args
+	switch {
+	case isCall(call, "Timestamp", "set") && len(args) == 1:
+		if now, ok := args[0].Value.(uint64); ok {
+			blockResponse.Timestamp = int64(now)
+		}
+	case isCall(call, "XAssets", "transfer") && len(args) == 4:
+		blockEx.Type = "transfer"
+		blockEx.ToAddress = fmt.Sprint(args[0].Value)
+		blockEx.Token, _ = args[1].Value.(string)
+		blockEx.Amount = fmt.Sprint(args[2].Value)
+		blockEx.Memo, _ = args[3].Value.(string)
 	}
-	blockHash := blockResponse.BlockHash
-	var (
-		respData []byte
-		err      error
-	)
-	respData, err = client.Rpc.SendRequest("chainx_getExtrinsicsEventsByBlockHash", []interface{}{blockHash})
-	fmt.Println(string(respData))
-	if err != nil || len(respData) == 0 {
-		return fmt.Errorf("get blockhash=[%s] event error,err=%v", blockHash, err)
+}
+
+//没有metadata时使用tx.CallIdTransfer和tx.CallIdTimestamp解析转账和时间戳，其他的call只保留原始数据
+func parseLegacyCall(blockResponse *model.ChainXBlockResponse, blockEx *model.ChainXExtrinsicResponse, extrinsic string) {
+	data, _ := hex.DecodeString(util.RemoveHex0x(extrinsic))
+	ce := tx.NewChainXExtrinsic(data)
+	if err := ce.ParseChainXExtrinsic(); err != nil {
+		blockEx.CallError = err.Error()
+		return
 	}
-	var eventResp model.ChainXBlockEventResponse
-	err = json.Unmarshal(respData, &eventResp)
+	switch ce.CallIndex {
+	case tx.CallIdTimestamp:
+		blockEx.Module, blockEx.Call, blockEx.Type = "Timestamp", "set", "set"
+		blockResponse.Timestamp = ce.Timestamp
+	case tx.CallIdTransfer:
+		blockEx.Module, blockEx.Call, blockEx.Type = "XAssets", "transfer", "transfer"
+		blockEx.ToAddress = ce.To
+		blockEx.Token = ce.Token
+		blockEx.Amount = fmt.Sprint(ce.Amount)
+		blockEx.Memo = ce.Memo
+	}
+}
+
+func isCall(call *tx.DecodedCall, module, name string) bool {
+	return isModule(call.Module, module) && call.Call == name
+}
+
+/*
+blockHash时的metadata，blockHash为空时为最新的metadata
+metadata按区块的spec version缓存，运行时升级前的区块使用升级前的metadata解析
+*/
+func (client *Client) loadMetadata(ctx context.Context, blockHash string) (*metadata.Metadata, error) {
+	version, err := client.GetRuntimeVersionContext(ctx, blockHash)
 	if err != nil {
-		return fmt.Errorf("blockHash=[%s] paese event error,Err=[%v]", blockHash, err)
+		return nil, err
 	}
-	if eventResp.Events == nil {
-		return nil
+	if blockHash == "" {
+		//http连接不能订阅运行版本，每次获取最新的metadata时检查运行时升级
+		client.setRuntimeVersion(version)
 	}
-	var extrinsicArray []*model.ChainXExtrinsicResponse
-	for k, event := range eventResp.Events {
-		if len(event) <= 1 {
-			continue
+	if meta := client.cachedMetadata(version.SpecVersion); meta != nil {
+		return meta, nil
+	}
+	if blockHash == "" {
+		//获取最新区块的hash，保证metadata和spec version属于同一个区块
+		respData, err := client.Rpc.CallContext(ctx, "chain_getBlockHash", nil)
+		if err != nil {
+			return nil, fmt.Errorf("get block hash error,err=%w", err)
 		}
-		extrinsic := new(model.ChainXExtrinsicResponse)
-		extrinsic.Status = "failed"
-		extrinsicIdx, _ := strconv.ParseInt(k, 10, 32)
-		extrinsic.ExtrinsicIndex = int(extrinsicIdx)
-		var feeArrey []*model.ChainXEventData
-		for _, e := range event {
-			if e == "system(ExtrinsicSuccess)" {
-				extrinsic.Status = "success"
-			} else if strings.Contains(e, model.XFEEMANAGE) {
-				//解析手续费
-				ced, _ := model.ParseChainXEventData(e)
-				if ced != nil {
-					feeArrey = append(feeArrey, ced)
-				}
-			}
+		if len(respData) == 0 {
+			return nil, errors.New("get block hash error,block hash is empty")
 		}
-		var fee int64
-		if len(feeArrey) > 0 {
-			for _, f := range feeArrey {
-				fee += f.Amount
-			}
+		blockHash = string(respData)
+		if version, err = client.GetRuntimeVersionContext(ctx, blockHash); err != nil {
+			return nil, err
 		}
-		extrinsic.Fee = fmt.Sprintf("%d", fee)
-		extrinsicArray = append(extrinsicArray, extrinsic)
 	}
-	//和blockresponse进行比较
-	for _, ex := range extrinsicArray {
-		for _, extrinsic := range blockResponse.Extrinsic {
-			if ex.ExtrinsicIndex == extrinsic.ExtrinsicIndex {
-				extrinsic.Status = ex.Status
-				extrinsic.Fee = ex.Fee
-			}
-		}
+	return client.metadataOf(ctx, version.SpecVersion, blockHash)
+}
+
+//specVersion的metadata，没有缓存时获取blockHash时的metadata，blockHash的spec version必须是specVersion
+func (client *Client) metadataOf(ctx context.Context, specVersion uint32, blockHash string) (*metadata.Metadata, error) {
+	if meta := client.cachedMetadata(specVersion); meta != nil {
+		return meta, nil
 	}
-	return nil
+	meta, err := client.GetMetadataContext(ctx, blockHash)
+	if err != nil {
+		return nil, err
+	}
+	client.mu.Lock()
+	if client.metas == nil {
+		client.metas = make(map[uint32]*metadata.Metadata)
+	}
+	client.metas[specVersion] = meta
+	client.mu.Unlock()
+	return meta, nil
+}
+
+func (client *Client) cachedMetadata(specVersion uint32) *metadata.Metadata {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	return client.metas[specVersion]
 }
+
 func (*Client) createTxHash(extrinsic string) string {
 	data, _ := hex.DecodeString(util.RemoveHex0x(extrinsic))
 	d := blake2b.Sum256(data)
@@ -187,35 +527,73 @@ func (*Client) createTxHash(extrinsic string) string {
 }
 
 func (client *Client) GetAccountNonce(address string) (uint64, error) {
+	return client.GetAccountNonceContext(context.Background(), address)
+}
+
+func (client *Client) GetAccountNonceContext(ctx context.Context, address string) (uint64, error) {
+	return client.GetAccountNonceAtContext(ctx, address, "")
+}
+
+//blockHash时账户的nonce
+func (client *Client) GetAccountNonceAt(address, blockHash string) (uint64, error) {
+	return client.GetAccountNonceAtContext(context.Background(), address, blockHash)
+}
+
+func (client *Client) GetAccountNonceAtContext(ctx context.Context, address, blockHash string) (uint64, error) {
 	pub, err := ss58.DecodeToPub(address)
 	if err != nil {
 		return 0, err
 	}
-	// 因为metadata版本小于8 ，所以不需要添加prefix
-	stringsKey := []byte("System" + " " + "AccountNonce")
-	var key []byte
-	hasher, err := blake2b.New256(nil)
+	meta, err := client.loadMetadata(ctx, blockHash)
+	if isMetadataUnavailable(err) {
+		//metadata版本不支持时直接使用ChainX 1.0的storage key查询
+		return client.getAccountNonceByKey(ctx, pub, blockHash)
+	}
 	if err != nil {
 		return 0, err
 	}
-	_, err = hasher.Write(append(stringsKey, pub...))
+	nonce, err := client.queryStorage(ctx, meta, blockHash, "System", "AccountNonce", pub)
+	if errors.Is(err, metadata.ErrStorageNotFound) {
+		//metadata中没有System.AccountNonce
+		return client.getAccountNonceByKey(ctx, pub, blockHash)
+	}
 	if err != nil {
 		return 0, err
 	}
-	key = hasher.Sum(nil)
-	k := "0x" + hex.EncodeToString(key)
-	resp, err2 := client.Rpc.SendRequest("state_getStorage", []interface{}{k})
-	if err2 != nil {
-		return 0, err2
+	//新账户的nonce不存在，返回默认值0
+	if nonce == nil {
+		return 0, nil
 	}
-	nonceHex := string(resp)
-	nonceData, err3 := hex.DecodeString(util.RemoveHex0x(nonceHex))
-	if err3 != nil {
-		return 0, err3
+	n, ok := nonce.(uint64)
+	if !ok {
+		return 0, fmt.Errorf("account nonce is %T not uint64", nonce)
 	}
-	return binary.LittleEndian.Uint64(nonceData), nil
+	return n, nil
 }
 
-func createPrefixedKey(module, fn string) []byte {
-	return append(xxhash.New128([]byte(module)).Sum(nil), xxhash.New128([]byte(fn)).Sum(nil)...)
+/*
+不使用metadata查询nonce，storage key为V8之前的格式 blake2_256("System AccountNonce" + 公钥)
+值为小端的u64，不存在时为0
+*/
+func (client *Client) getAccountNonceByKey(ctx context.Context, pub []byte, blockHash string) (uint64, error) {
+	key := blake2b.Sum256(append([]byte("System AccountNonce"), pub...))
+	params := []interface{}{"0x" + hex.EncodeToString(key[:])}
+	if blockHash != "" {
+		params = append(params, blockHash)
+	}
+	respData, err := client.Rpc.CallContext(ctx, "state_getStorage", params)
+	if errors.Is(err, util.ErrNullResult) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("get account nonce error,err=%w", err)
+	}
+	data, err := hex.DecodeString(util.RemoveHex0x(string(respData)))
+	if err != nil {
+		return 0, fmt.Errorf("decode account nonce hex error,err=%v", err)
+	}
+	if len(data) != 8 {
+		return 0, fmt.Errorf("account nonce has %d bytes,expect 8", len(data))
+	}
+	return binary.LittleEndian.Uint64(data), nil
 }
@@ -0,0 +1,148 @@
+package rpc
+
+import (
+	"encoding/hex"
+	"errors"
+	"github.com/JFJun/chainX-go/model"
+	"github.com/JFJun/chainX-go/ss58"
+	"github.com/JFJun/chainX-go/util"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSubmitExtrinsicError(t *testing.T) {
+	mock := util.NewMockTransport()
+	mock.Add("author_submitExtrinsic", []interface{}{"0x01"}, "0x02")
+	mock.AddError("author_submitExtrinsic", []interface{}{"0x03"}, &util.RPCError{
+		Code:    util.ErrCodeInvalidTransaction,
+		Message: "Invalid Transaction",
+		Data:    "Transaction is outdated",
+	})
+	client := NewWithTransport(mock)
+	hash, err := client.SubmitExtrinsic("0x01")
+	if err != nil || hash != "0x02" {
+		t.Fatalf("tx hash is %s,err=%v", hash, err)
+	}
+	//节点返回的错误被包装后仍然可以判断错误码
+	if _, err := client.SubmitExtrinsic("0x03"); !util.IsStaleNonce(err) {
+		t.Fatalf("submit extrinsic error is %v", err)
+	}
+}
+
+func TestGetChainProperties(t *testing.T) {
+	mock := util.NewMockTransport()
+	mock.AddError("system_properties", nil, util.ErrNullResult)
+	client := NewWithTransport(mock)
+	//节点没有设置properties时使用ChainX的默认值
+	properties, err := client.GetChainProperties()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if properties.SS58Format != defaultSS58Prefix || properties.TokenDecimals != defaultTokenDecimals || properties.TokenSymbol != defaultTokenSymbol {
+		t.Fatalf("properties are %+v", properties)
+	}
+
+	mock.Add("system_properties", nil, map[string]interface{}{"ss58Format": 42, "tokenDecimals": 12, "tokenSymbol": "DOT"})
+	properties, err = client.GetChainProperties()
+	if err != nil || properties.SS58Format != 42 || properties.TokenDecimals != 12 || properties.TokenSymbol != "DOT" {
+		t.Fatalf("properties are %+v,err=%v", properties, err)
+	}
+
+	mock.AddError("system_properties", nil, &util.RPCError{Code: util.ErrCodeMethodNotFound, Message: "Method not found"})
+	if _, err := client.GetChainProperties(); !util.IsMethodNotFound(err) {
+		t.Fatalf("get chain properties error is %v", err)
+	}
+}
+
+func TestNewWithRetryAndTimeout(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x01"}`))
+		default:
+			time.Sleep(200 * time.Millisecond)
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x01"}`))
+		}
+	}))
+	defer server.Close()
+	client, err := New(server.URL, "", "",
+		WithRetry(util.RetryConfig{MaxRetries: 1, MinBackoff: time.Millisecond}),
+		WithTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	//第一次返回503，重试后成功
+	hash, err := client.Rpc.Call("chain_getBlockHash", []interface{}{1})
+	if err != nil || string(hash) != "0x01" || atomic.LoadInt32(&calls) != 2 {
+		t.Fatalf("block hash is %s,calls=%d,err=%v", hash, atomic.LoadInt32(&calls), err)
+	}
+	if _, err := client.Rpc.Call("chain_getBlockHash", []interface{}{1}); err == nil {
+		t.Fatal("slow request: expect timeout error")
+	}
+}
+
+func TestGetBlockByNumberNotFound(t *testing.T) {
+	mock := util.NewMockTransport()
+	mock.AddError("chain_getBlockHash", []interface{}{100}, util.ErrNullResult)
+	client := NewWithTransport(mock)
+	//高度超过最新区块时节点返回null
+	if _, err := client.GetBlockByNumber(100); !errors.Is(err, util.ErrNullResult) {
+		t.Fatalf("get block error is %v", err)
+	}
+}
+
+func TestGetAccountNonceAt(t *testing.T) {
+	pub, _ := hex.DecodeString("d43593c715fdd31c61141abd04a99fd6822c8558854ccde39a5684e7a56da27d")
+	address, _ := ss58.Encode(pub, ss58.ChainXPrefix)
+	//ChainX 1.0的key blake2_256("System AccountNonce" + 公钥)，和V7 metadata生成的key相同
+	key := "0x5c54163a1c72509b5250f0a30b9001fdee9d9b48388b06921f1b210e81e3a1f0"
+	mock := util.NewMockTransport()
+	client := NewWithTransport(mock)
+	tests := []struct {
+		metadata string
+		nonce    string
+		expect   uint64
+	}{
+		//metadata版本不支持
+		{testMetadataV6, "0x0500000000000000", 5},
+		{testFixture(t, "chainx_v7.hex"), "0x0600000000000000", 6},
+		//metadata中没有System.AccountNonce
+		{testFixture(t, "substrate_v12.hex"), "0x0700000000000000", 7},
+		//新账户没有nonce
+		{testFixture(t, "chainx_v7.hex"), "", 0},
+	}
+	for i, test := range tests {
+		blockHash := testBlockHash(i + 1)
+		mock.Add("state_getRuntimeVersion", []interface{}{blockHash}, model.RuntimeVersion{SpecName: "chainx", SpecVersion: uint32(i + 1)})
+		mock.Add("state_getMetadata", []interface{}{blockHash}, test.metadata)
+		if test.nonce == "" {
+			mock.AddError("state_getStorage", []interface{}{key, blockHash}, util.ErrNullResult)
+		} else {
+			mock.Add("state_getStorage", []interface{}{key, blockHash}, test.nonce)
+		}
+		nonce, err := client.GetAccountNonceAt(address, blockHash)
+		if err != nil {
+			t.Errorf("block %d: %v", i+1, err)
+			continue
+		}
+		if nonce != test.expect {
+			t.Errorf("block %d nonce is %d,expect %d", i+1, nonce, test.expect)
+		}
+	}
+
+	//获取metadata的其他错误直接返回，不使用ChainX 1.0的key查询
+	blockHash := testBlockHash(10)
+	mock.Add("state_getRuntimeVersion", []interface{}{blockHash}, model.RuntimeVersion{SpecName: "chainx", SpecVersion: 10})
+	mock.AddError("state_getMetadata", []interface{}{blockHash}, errors.New("connection refused"))
+	mock.Add("state_getStorage", []interface{}{key, blockHash}, "0x0800000000000000")
+	if _, err := client.GetAccountNonceAt(address, blockHash); err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Fatalf("get account nonce error is %v", err)
+	}
+}
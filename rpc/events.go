@@ -0,0 +1,107 @@
+package rpc
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/JFJun/chainX-go/events"
+	"github.com/JFJun/chainX-go/metadata"
+	"github.com/JFJun/chainX-go/model"
+	"github.com/JFJun/chainX-go/util"
+	"strings"
+)
+
+const (
+	eventStatusSuccess = "success"
+	eventStatusFailed  = "failed"
+)
+
+//从System Events storage获取并解析区块中的事件
+func (client *Client) GetEvents(blockHash string) ([]*events.EventRecord, error) {
+	return client.GetEventsContext(context.Background(), blockHash)
+}
+
+//使用blockHash时的metadata解析
+func (client *Client) GetEventsContext(ctx context.Context, blockHash string) ([]*events.EventRecord, error) {
+	meta, err := client.loadMetadata(ctx, blockHash)
+	if err != nil {
+		return nil, err
+	}
+	return client.getEvents(ctx, meta, blockHash)
+}
+
+func (client *Client) getEvents(ctx context.Context, meta *metadata.Metadata, blockHash string) ([]*events.EventRecord, error) {
+	key, err := eventsStorageKey(meta)
+	if err != nil {
+		return nil, err
+	}
+	respData, err := client.Rpc.CallContext(ctx, "state_getStorage", []interface{}{key, blockHash})
+	//区块中没有事件时storage不存在
+	if errors.Is(err, util.ErrNullResult) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get blockhash=[%s] event error,err=%w", blockHash, err)
+	}
+	if len(respData) == 0 {
+		return nil, fmt.Errorf("get blockhash=[%s] event error,event is empty", blockHash)
+	}
+	return events.DecodeEventRecordsHex(meta, string(respData))
+}
+
+func eventsStorageKey(meta *metadata.Metadata) (string, error) {
+	key, _, err := meta.StorageKey("System", "Events")
+	if err != nil {
+		return "", err
+	}
+	return "0x" + hex.EncodeToString(key), nil
+}
+
+/*
+根据事件设置交易的状态和手续费
+System.ExtrinsicSuccess为成功，System.ExtrinsicFailed为失败，手续费为XFeeManager事件中金额的和
+*/
+func (client *Client) parseTxEvent(blockResponse *model.ChainXBlockResponse, records []*events.EventRecord) error {
+	if blockResponse == nil {
+		return errors.New("block is null ptr")
+	}
+	fees := make(map[int]uint64)
+	status := make(map[int]string)
+	for _, record := range records {
+		if record.Phase != events.PhaseApplyExtrinsic {
+			continue
+		}
+		switch {
+		case isEvent(record, "System", "ExtrinsicSuccess"):
+			status[record.ExtrinsicIndex] = eventStatusSuccess
+		case isEvent(record, "System", "ExtrinsicFailed"):
+			status[record.ExtrinsicIndex] = eventStatusFailed
+		case isModule(record.Module, "XFeeManager"):
+			//不是手续费的事件，或者使用Register替换了解析函数时跳过
+			fee, err := events.Parse(record)
+			if err != nil {
+				continue
+			}
+			if f, ok := fee.(*events.XFeeManagerFee); ok {
+				fees[record.ExtrinsicIndex] += f.Amount
+			}
+		}
+	}
+	for _, extrinsic := range blockResponse.Extrinsic {
+		if s, ok := status[extrinsic.ExtrinsicIndex]; ok {
+			extrinsic.Status = s
+		}
+		extrinsic.Fee = fmt.Sprintf("%d", fees[extrinsic.ExtrinsicIndex])
+	}
+	return nil
+}
+
+func isEvent(record *events.EventRecord, module, event string) bool {
+	return isModule(record.Module, module) && record.Event == event
+}
+
+//较早的运行时中module的名字为xfee_manager这样的格式
+func isModule(name, module string) bool {
+	return strings.EqualFold(strings.Replace(name, "_", "", -1), module)
+}
@@ -0,0 +1,50 @@
+package rpc
+
+import (
+	codec "github.com/JFJun/chainX-go/codes"
+	"github.com/JFJun/chainX-go/events"
+	"github.com/JFJun/chainX-go/model"
+	"testing"
+)
+
+func TestParseTxEvent(t *testing.T) {
+	//XFeeManager中不是手续费的事件，解析结果不是*events.XFeeManagerFee
+	events.Register("XFeeManager", "TestFeeRate", func(record *events.EventRecord) (interface{}, error) {
+		return record.Args[0].Value, nil
+	})
+	fee := func(index int, name string, amount uint64) *events.EventRecord {
+		return &events.EventRecord{Phase: events.PhaseApplyExtrinsic, ExtrinsicIndex: index, Module: "XFeeManager", Event: name,
+			Args: []codec.NamedValue{{Type: "AccountId", Value: "5Alice"}, {Type: "Balance", Value: amount}}}
+	}
+	records := []*events.EventRecord{
+		fee(0, "FeeForProducer", 10),
+		fee(0, "FeeForJackpot", 90),
+		{Phase: events.PhaseApplyExtrinsic, ExtrinsicIndex: 0, Module: "System", Event: "ExtrinsicSuccess"},
+		{Phase: events.PhaseApplyExtrinsic, ExtrinsicIndex: 1, Module: "XFeeManager", Event: "TestFeeRate",
+			Args: []codec.NamedValue{{Type: "u32", Value: uint64(5)}}},
+		//参数不对的事件不计算手续费
+		{Phase: events.PhaseApplyExtrinsic, ExtrinsicIndex: 1, Module: "XFeeManager", Event: "FeeForCouncil"},
+		{Phase: events.PhaseApplyExtrinsic, ExtrinsicIndex: 1, Module: "System", Event: "ExtrinsicFailed"},
+		//不是ApplyExtrinsic的事件不计算
+		{Phase: events.PhaseFinalization, ExtrinsicIndex: -1, Module: "XFeeManager", Event: "FeeForProducer"},
+	}
+	block := &model.ChainXBlockResponse{Extrinsic: []*model.ChainXExtrinsicResponse{
+		{ExtrinsicIndex: 0}, {ExtrinsicIndex: 1}, {ExtrinsicIndex: 2},
+	}}
+	client := NewWithTransport(nil)
+	if err := client.parseTxEvent(block, records); err != nil {
+		t.Fatal(err)
+	}
+	for i, expect := range []struct{ status, fee string }{
+		{eventStatusSuccess, "100"},
+		{eventStatusFailed, "0"},
+		{"", "0"},
+	} {
+		if ex := block.Extrinsic[i]; ex.Status != expect.status || ex.Fee != expect.fee {
+			t.Errorf("extrinsic %d status %s fee %s,expect %s %s", i, ex.Status, ex.Fee, expect.status, expect.fee)
+		}
+	}
+	if err := client.parseTxEvent(nil, records); err == nil {
+		t.Error("nil block: expect error")
+	}
+}
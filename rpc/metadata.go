@@ -0,0 +1,37 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/JFJun/chainX-go/metadata"
+	"github.com/JFJun/chainX-go/util"
+)
+
+//获取指定区块的metadata，blockHash为空时获取最新区块的metadata
+func (client *Client) GetMetadata(blockHash string) (*metadata.Metadata, error) {
+	return client.GetMetadataContext(context.Background(), blockHash)
+}
+
+func (client *Client) GetMetadataContext(ctx context.Context, blockHash string) (*metadata.Metadata, error) {
+	var params []interface{}
+	if blockHash != "" {
+		params = []interface{}{blockHash}
+	}
+	respData, err := client.Rpc.CallContext(ctx, "state_getMetadata", params)
+	if err != nil {
+		return nil, fmt.Errorf("get metadata error,err=%w", err)
+	}
+	if len(respData) == 0 {
+		return nil, errors.New("get metadata error,metadata is empty")
+	}
+	return metadata.DecodeHex(string(respData))
+}
+
+/*
+节点不能提供可以解析的metadata，例如metadata版本不支持或者节点不支持state_getMetadata
+这时按ChainX 1.0固定的call id和storage key处理，网络错误，ctx取消等其他错误直接返回
+*/
+func isMetadataUnavailable(err error) bool {
+	return errors.Is(err, metadata.ErrUnsupportedVersion) || util.IsMethodNotFound(err)
+}
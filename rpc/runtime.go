@@ -0,0 +1,246 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/JFJun/chainX-go/model"
+	"github.com/JFJun/chainX-go/util"
+	"time"
+)
+
+//system_properties 没有配置时使用的默认值
+const (
+	defaultSS58Prefix    = 44
+	defaultTokenDecimals = 8
+	defaultTokenSymbol   = "PCX"
+)
+
+type options struct {
+	initRuntime  bool
+	watchRuntime bool
+	retry        *util.RetryConfig
+	timeout      *time.Duration
+}
+
+type Option func(o *options)
+
+//创建Client时获取运行版本，genesis hash和链的属性
+func WithRuntimeInit() Option {
+	return func(o *options) {
+		o.initRuntime = true
+	}
+}
+
+/*
+创建Client时获取运行版本等信息，并订阅运行版本的变化
+运行时升级后刷新运行版本并清除缓存的资产信息，http连接时在获取最新的metadata时检查
+*/
+func WithRuntimeWatch() Option {
+	return func(o *options) {
+		o.initRuntime = true
+		o.watchRuntime = true
+	}
+}
+
+func (client *Client) GetRuntimeVersion(blockHash string) (*model.RuntimeVersion, error) {
+	return client.GetRuntimeVersionContext(context.Background(), blockHash)
+}
+
+//blockHash为空时获取最新的运行版本
+func (client *Client) GetRuntimeVersionContext(ctx context.Context, blockHash string) (*model.RuntimeVersion, error) {
+	var params []interface{}
+	if blockHash != "" {
+		params = []interface{}{blockHash}
+	}
+	respData, err := client.Rpc.CallContext(ctx, "state_getRuntimeVersion", params)
+	if err != nil {
+		return nil, fmt.Errorf("get runtime version error,err=%w", err)
+	}
+	version := new(model.RuntimeVersion)
+	if err := json.Unmarshal(respData, version); err != nil {
+		return nil, fmt.Errorf("parse runtime version error,err=%v", err)
+	}
+	return version, nil
+}
+
+func (client *Client) GetChainProperties() (*model.ChainProperties, error) {
+	return client.GetChainPropertiesContext(context.Background())
+}
+
+func (client *Client) GetChainPropertiesContext(ctx context.Context) (*model.ChainProperties, error) {
+	properties := &model.ChainProperties{
+		SS58Format:    defaultSS58Prefix,
+		TokenDecimals: defaultTokenDecimals,
+		TokenSymbol:   defaultTokenSymbol,
+	}
+	respData, err := client.Rpc.CallContext(ctx, "system_properties", nil)
+	if errors.Is(err, util.ErrNullResult) {
+		return properties, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get chain properties error,err=%w", err)
+	}
+	if err := json.Unmarshal(respData, properties); err != nil {
+		return nil, fmt.Errorf("parse chain properties error,err=%v", err)
+	}
+	return properties, nil
+}
+
+//获取运行版本，genesis hash和链的属性，保存在Client中
+func (client *Client) InitRuntime(ctx context.Context) error {
+	if _, err := client.GetGenesisHashContext(ctx); err != nil {
+		return err
+	}
+	properties, err := client.GetChainPropertiesContext(ctx)
+	if err != nil {
+		return err
+	}
+	client.mu.Lock()
+	client.coinType = properties.TokenSymbol
+	client.ss58Prefix = properties.SS58Format
+	client.tokenDecimals = properties.TokenDecimals
+	client.mu.Unlock()
+	_, err = client.RefreshRuntimeContext(ctx)
+	return err
+}
+
+//链的token symbol，InitRuntime之前为空
+func (client *Client) CoinType() string {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	return client.coinType
+}
+
+//InitRuntime或者GetGenesisHash之后保存的genesis hash，之前为空
+func (client *Client) GenesisHash() string {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	return client.genesisHash
+}
+
+//链的ss58地址前缀，InitRuntime之前为0
+func (client *Client) SS58Prefix() uint8 {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	return client.ss58Prefix
+}
+
+//链的token精度，InitRuntime之前为0
+func (client *Client) TokenDecimals() int {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	return client.tokenDecimals
+}
+
+func (client *Client) RefreshRuntime() (bool, error) {
+	return client.RefreshRuntimeContext(context.Background())
+}
+
+/*
+重新获取运行版本，返回运行时是否升级
+升级后清除缓存的资产信息，下次使用时重新获取
+*/
+func (client *Client) RefreshRuntimeContext(ctx context.Context) (bool, error) {
+	version, err := client.GetRuntimeVersionContext(ctx, "")
+	if err != nil {
+		return false, err
+	}
+	return client.setRuntimeVersion(version), nil
+}
+
+//InitRuntime，RefreshRuntime或者获取最新的metadata后保存的运行版本，之前为0
+func (client *Client) RuntimeVersion() (specVersion, transactionVersion uint32) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	return client.specVersion, client.transactionVersion
+}
+
+func (client *Client) setRuntimeVersion(version *model.RuntimeVersion) bool {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	upgraded := client.specVersion != 0 && client.specVersion != version.SpecVersion
+	client.specVersion = version.SpecVersion
+	client.transactionVersion = version.TransactionVersion
+	if upgraded {
+		client.assets = nil
+	}
+	return upgraded
+}
+
+//订阅结束后重新订阅的间隔
+var runtimeResubscribeInterval = 5 * time.Second
+
+/*
+订阅运行版本的变化
+订阅被节点结束或者重连后重新订阅失败时，每隔runtimeResubscribeInterval重新订阅，直到Close
+重新订阅成功后刷新一次运行版本，避免错过订阅中断期间的升级
+*/
+func (client *Client) watchRuntime() error {
+	closing := make(chan struct{})
+	client.mu.Lock()
+	client.closing = closing
+	client.mu.Unlock()
+	sub, err := client.subscribeRuntime(closing)
+	if err != nil {
+		return err
+	}
+	go client.runRuntimeWatch(sub, closing)
+	return nil
+}
+
+func (client *Client) subscribeRuntime(closing chan struct{}) (*util.Subscription, error) {
+	sub, err := client.subscribe(context.Background(), "state_subscribeRuntimeVersion", "state_unsubscribeRuntimeVersion", []interface{}{})
+	if err != nil {
+		return nil, err
+	}
+	client.mu.Lock()
+	select {
+	case <-closing:
+		//订阅时Client已经关闭
+		client.mu.Unlock()
+		sub.Unsubscribe()
+		return nil, errors.New("client is closed")
+	default:
+	}
+	client.runtimeSub = sub
+	client.mu.Unlock()
+	return sub, nil
+}
+
+func (client *Client) runRuntimeWatch(sub *util.Subscription, closing chan struct{}) {
+	for {
+		select {
+		case raw := <-sub.Notifications():
+			version := new(model.RuntimeVersion)
+			if err := json.Unmarshal(raw, version); err != nil {
+				continue
+			}
+			client.setRuntimeVersion(version)
+		case <-closing:
+			return
+		case <-sub.Done():
+			if sub = client.resubscribeRuntime(closing); sub == nil {
+				return
+			}
+		}
+	}
+}
+
+//重新订阅运行版本，Client关闭时返回nil
+func (client *Client) resubscribeRuntime(closing chan struct{}) *util.Subscription {
+	for {
+		select {
+		case <-closing:
+			return nil
+		case <-time.After(runtimeResubscribeInterval):
+		}
+		sub, err := client.subscribeRuntime(closing)
+		if err != nil {
+			continue
+		}
+		client.RefreshRuntimeContext(context.Background())
+		return sub
+	}
+}
@@ -0,0 +1,86 @@
+package rpc
+
+import (
+	"context"
+	"github.com/JFJun/chainX-go/model"
+	"github.com/JFJun/chainX-go/util"
+	"testing"
+	"time"
+)
+
+func waitSpecVersion(t *testing.T, client *Client, expect uint32) {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if specVersion, _ := client.RuntimeVersion(); specVersion == expect {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	specVersion, _ := client.RuntimeVersion()
+	t.Fatalf("spec version is %d,expect %d", specVersion, expect)
+}
+
+func TestInitRuntime(t *testing.T) {
+	mock := util.NewMockTransport()
+	mock.Add("chain_getBlockHash", []interface{}{0}, testBlockHash(0))
+	mock.Add("system_properties", nil, map[string]interface{}{"ss58Format": 42, "tokenDecimals": 12, "tokenSymbol": "DOT"})
+	mock.Add("state_getRuntimeVersion", nil, model.RuntimeVersion{SpecName: "chainx", SpecVersion: 3, TransactionVersion: 1})
+	client := NewWithTransport(mock)
+	if client.CoinType() != "" || client.GenesisHash() != "" || client.SS58Prefix() != 0 || client.TokenDecimals() != 0 {
+		t.Fatal("runtime fields are set before InitRuntime")
+	}
+	if err := client.InitRuntime(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if client.CoinType() != "DOT" || client.GenesisHash() != testBlockHash(0) || client.SS58Prefix() != 42 || client.TokenDecimals() != 12 {
+		t.Fatalf("runtime fields are %s %s %d %d", client.CoinType(), client.GenesisHash(), client.SS58Prefix(), client.TokenDecimals())
+	}
+	if specVersion, transactionVersion := client.RuntimeVersion(); specVersion != 3 || transactionVersion != 1 {
+		t.Fatalf("runtime version is %d %d", specVersion, transactionVersion)
+	}
+}
+
+func TestWatchRuntimeResubscribe(t *testing.T) {
+	interval := runtimeResubscribeInterval
+	runtimeResubscribeInterval = 10 * time.Millisecond
+	defer func() { runtimeResubscribeInterval = interval }()
+
+	mock := util.NewMockTransport()
+	mock.AddSubscription("state_subscribeRuntimeVersion", nil, model.RuntimeVersion{SpecVersion: 1})
+	client := NewWithTransport(mock)
+	if err := client.watchRuntime(); err != nil {
+		t.Fatal(err)
+	}
+	waitSpecVersion(t, client, 1)
+
+	//订阅被结束后重新订阅，并刷新运行版本
+	mock.AddSubscription("state_subscribeRuntimeVersion", nil, model.RuntimeVersion{SpecVersion: 2})
+	mock.Add("state_getRuntimeVersion", nil, model.RuntimeVersion{SpecVersion: 2})
+	client.mu.Lock()
+	sub := client.runtimeSub
+	client.mu.Unlock()
+	sub.Unsubscribe()
+	waitSpecVersion(t, client, 2)
+	client.mu.Lock()
+	resubscribed := client.runtimeSub
+	client.mu.Unlock()
+	if resubscribed == sub {
+		t.Fatal("runtime version is not resubscribed")
+	}
+
+	//Close后不再重新订阅
+	if err := client.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-resubscribed.Done():
+	case <-time.After(time.Second):
+		t.Fatal("subscription is not closed")
+	}
+	time.Sleep(5 * runtimeResubscribeInterval)
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if client.runtimeSub != nil {
+		t.Fatal("runtime version is resubscribed after close")
+	}
+}
@@ -0,0 +1,120 @@
+package rpc
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	codec "github.com/JFJun/chainX-go/codes"
+	"github.com/JFJun/chainX-go/metadata"
+	"github.com/JFJun/chainX-go/model"
+	"github.com/JFJun/chainX-go/util"
+)
+
+/*
+查询storage，key和返回值的类型由metadata决定
+例如 QueryStorage("System", "AccountNonce", address)
+storage不存在时，Default的storage返回默认值，Optional的storage返回nil
+返回值的类型和codec.TypeDecoder.Decode相同
+*/
+func (client *Client) QueryStorage(module, item string, keys ...interface{}) (interface{}, error) {
+	return client.QueryStorageContext(context.Background(), module, item, keys...)
+}
+
+func (client *Client) QueryStorageContext(ctx context.Context, module, item string, keys ...interface{}) (interface{}, error) {
+	return client.QueryStorageAtContext(ctx, "", module, item, keys...)
+}
+
+//查询blockHash时的storage，使用blockHash时的metadata，blockHash为空时查询最新的状态
+func (client *Client) QueryStorageAt(blockHash, module, item string, keys ...interface{}) (interface{}, error) {
+	return client.QueryStorageAtContext(context.Background(), blockHash, module, item, keys...)
+}
+
+func (client *Client) QueryStorageAtContext(ctx context.Context, blockHash, module, item string, keys ...interface{}) (interface{}, error) {
+	meta, err := client.loadMetadata(ctx, blockHash)
+	if err != nil {
+		return nil, err
+	}
+	return client.queryStorage(ctx, meta, blockHash, module, item, keys...)
+}
+
+func (client *Client) queryStorage(ctx context.Context, meta *metadata.Metadata, blockHash, module, item string, keys ...interface{}) (interface{}, error) {
+	key, entry, err := meta.StorageKey(module, item, keys...)
+	if err != nil {
+		return nil, err
+	}
+	params := []interface{}{"0x" + hex.EncodeToString(key)}
+	if blockHash != "" {
+		params = append(params, blockHash)
+	}
+	respData, err := client.Rpc.CallContext(ctx, "state_getStorage", params)
+	if err != nil && !errors.Is(err, util.ErrNullResult) {
+		return nil, fmt.Errorf("query storage %s.%s error,err=%w", module, item, err)
+	}
+	var data []byte
+	if err == nil {
+		data, err = hex.DecodeString(util.RemoveHex0x(string(respData)))
+		if err != nil {
+			return nil, fmt.Errorf("decode storage %s.%s hex error,err=%v", module, item, err)
+		}
+	}
+	return decodeStorageValue(entry, data)
+}
+
+//根据metadata生成storage key的hex，可以用于QueryStorageRange和SubscribeStorage
+func (client *Client) StorageKey(module, item string, keys ...interface{}) (string, error) {
+	return client.StorageKeyContext(context.Background(), module, item, keys...)
+}
+
+func (client *Client) StorageKeyContext(ctx context.Context, module, item string, keys ...interface{}) (string, error) {
+	meta, err := client.loadMetadata(ctx, "")
+	if err != nil {
+		return "", err
+	}
+	key, _, err := meta.StorageKey(module, item, keys...)
+	if err != nil {
+		return "", err
+	}
+	return "0x" + hex.EncodeToString(key), nil
+}
+
+/*
+查询fromBlock到toBlock之间keys的变化，每个有变化的区块返回一个StorageChangeSet
+第一个区块返回keys当时的值，toBlock为空时查询到最新的区块
+*/
+func (client *Client) QueryStorageRange(keys []string, fromBlock, toBlock string) ([]*model.StorageChangeSet, error) {
+	return client.QueryStorageRangeContext(context.Background(), keys, fromBlock, toBlock)
+}
+
+func (client *Client) QueryStorageRangeContext(ctx context.Context, keys []string, fromBlock, toBlock string) ([]*model.StorageChangeSet, error) {
+	params := []interface{}{keys, fromBlock}
+	if toBlock != "" {
+		params = append(params, toBlock)
+	}
+	respData, err := client.Rpc.CallContext(ctx, "state_queryStorage", params)
+	if err != nil {
+		return nil, fmt.Errorf("query storage from %s to %s error,err=%w", fromBlock, toBlock, err)
+	}
+	var changeSets []*model.StorageChangeSet
+	if err := json.Unmarshal(respData, &changeSets); err != nil {
+		return nil, fmt.Errorf("parse storage change set error,err=%v", err)
+	}
+	return changeSets, nil
+}
+
+//data为空表示storage不存在
+func decodeStorageValue(entry *metadata.StorageEntry, data []byte) (interface{}, error) {
+	if len(data) == 0 {
+		if entry.Modifier != "Default" || len(entry.Default) == 0 {
+			return nil, nil
+		}
+		data = entry.Default
+	}
+	//linked map的值后面还有Linkage，只解析值的部分
+	v, err := codec.NewTypeDecoder(data).Decode(entry.Value)
+	if err != nil {
+		return nil, fmt.Errorf("decode storage %s.%s value error,err=%v", entry.Prefix, entry.Name, err)
+	}
+	return v, nil
+}
@@ -0,0 +1,161 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/JFJun/chainX-go/model"
+	"github.com/JFJun/chainX-go/util"
+)
+
+//deliver返回errSubscriptionDone表示订阅正常结束
+var errSubscriptionDone = errors.New("subscription done")
+
+/*
+subscription 把节点推送的原始数据解析后发送到对应的channel
+解析失败或者连接断开时，错误通过Err返回，然后Err和数据的channel被关闭
+*/
+type subscription struct {
+	sub *util.Subscription
+	err chan error
+}
+
+func (s *subscription) Err() <-chan error {
+	return s.err
+}
+
+func (s *subscription) Unsubscribe() error {
+	return s.sub.Unsubscribe()
+}
+
+//closeData关闭数据的channel，run退出时调用
+func (s *subscription) run(deliver func(raw json.RawMessage) error, closeData func()) {
+	defer close(s.err)
+	defer closeData()
+	for {
+		select {
+		case raw := <-s.sub.Notifications():
+			if err := deliver(raw); err != nil {
+				if err != errSubscriptionDone {
+					s.err <- err
+				}
+				s.sub.Unsubscribe()
+				return
+			}
+		case err, ok := <-s.sub.Err():
+			if ok {
+				s.err <- err
+			}
+			return
+		}
+	}
+}
+
+type HeaderSubscription struct {
+	subscription
+	headers chan *model.Header
+}
+
+func (s *HeaderSubscription) Headers() <-chan *model.Header {
+	return s.headers
+}
+
+type StorageSubscription struct {
+	subscription
+	changes chan *model.StorageChangeSet
+}
+
+func (s *StorageSubscription) Changes() <-chan *model.StorageChangeSet {
+	return s.changes
+}
+
+//订阅新区块头
+func (client *Client) SubscribeNewHeads() (*HeaderSubscription, error) {
+	return client.SubscribeNewHeadsContext(context.Background())
+}
+
+//ctx取消时自动取消订阅
+func (client *Client) SubscribeNewHeadsContext(ctx context.Context) (*HeaderSubscription, error) {
+	return client.subscribeHeads(ctx, "chain_subscribeNewHead", "chain_unsubscribeNewHead")
+}
+
+//订阅已经确认的区块头
+func (client *Client) SubscribeFinalizedHeads() (*HeaderSubscription, error) {
+	return client.SubscribeFinalizedHeadsContext(context.Background())
+}
+
+func (client *Client) SubscribeFinalizedHeadsContext(ctx context.Context) (*HeaderSubscription, error) {
+	return client.subscribeHeads(ctx, "chain_subscribeFinalizedHeads", "chain_unsubscribeFinalizedHeads")
+}
+
+//订阅storage的变化，keys为storage key的hex，0x开头
+func (client *Client) SubscribeStorage(keys []string) (*StorageSubscription, error) {
+	return client.SubscribeStorageContext(context.Background(), keys)
+}
+
+func (client *Client) SubscribeStorageContext(ctx context.Context, keys []string) (*StorageSubscription, error) {
+	sub, err := client.subscribe(ctx, "state_subscribeStorage", "state_unsubscribeStorage", []interface{}{keys})
+	if err != nil {
+		return nil, err
+	}
+	ss := &StorageSubscription{
+		subscription: subscription{sub: sub, err: make(chan error, 1)},
+		changes:      make(chan *model.StorageChangeSet),
+	}
+	go ss.run(func(raw json.RawMessage) error {
+		changeSet := new(model.StorageChangeSet)
+		if err := json.Unmarshal(raw, changeSet); err != nil {
+			return fmt.Errorf("parse storage change set error,err=%v", err)
+		}
+		select {
+		case ss.changes <- changeSet:
+		case <-sub.Done():
+		}
+		return nil
+	}, func() { close(ss.changes) })
+	return ss, nil
+}
+
+func (client *Client) subscribeHeads(ctx context.Context, method, unsubscribeMethod string) (*HeaderSubscription, error) {
+	sub, err := client.subscribe(ctx, method, unsubscribeMethod, []interface{}{})
+	if err != nil {
+		return nil, err
+	}
+	hs := &HeaderSubscription{
+		subscription: subscription{sub: sub, err: make(chan error, 1)},
+		headers:      make(chan *model.Header),
+	}
+	go hs.run(func(raw json.RawMessage) error {
+		header := new(model.Header)
+		if err := json.Unmarshal(raw, header); err != nil {
+			return fmt.Errorf("parse header error,err=%v", err)
+		}
+		select {
+		case hs.headers <- header:
+		case <-sub.Done():
+		}
+		return nil
+	}, func() { close(hs.headers) })
+	return hs, nil
+}
+
+func (client *Client) subscribe(ctx context.Context, method, unsubscribeMethod string, params []interface{}) (*util.Subscription, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	sub, err := client.Rpc.Subscribe(method, unsubscribeMethod, params)
+	if err != nil {
+		return nil, fmt.Errorf("subscribe %s error,err=%w", method, err)
+	}
+	if ctx.Done() != nil {
+		go func() {
+			select {
+			case <-ctx.Done():
+				sub.Unsubscribe()
+			case <-sub.Done():
+			}
+		}()
+	}
+	return sub, nil
+}
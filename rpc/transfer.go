@@ -0,0 +1,153 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/JFJun/chainX-go/model"
+	"github.com/JFJun/chainX-go/ss58"
+	"github.com/JFJun/chainX-go/tx"
+	"github.com/JFJun/chainX-go/util"
+)
+
+type TransferResult struct {
+	TxHash    string `json:"tx_hash"`
+	BlockHash string `json:"block_hash"` //交易被打包的区块，http连接无法订阅交易状态时为空
+}
+
+/*
+完成一笔转账：获取nonce和genesis hash，构造交易，签名，提交
+token必须是链上存在的资产，amount为最小单位，可以用AssetRegistry.ToBaseUnits转换
+websocket或ipc连接时会等待交易被打包，返回打包的区块hash
+*/
+func (client *Client) Transfer(ctx context.Context, signer tx.Signer, to, token string, amount uint64, memo string) (*TransferResult, error) {
+	from, err := ss58.Encode(signer.PublicKey(), ss58.ChainXPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("encode signer address error,err=%v", err)
+	}
+	unsigned, err := client.PrepareTransfer(ctx, from, to, token, amount, memo)
+	if err != nil {
+		return nil, err
+	}
+	extrinsic, err := unsigned.Sign(signer)
+	if err != nil {
+		return nil, fmt.Errorf("sign transaction error,err=%v", err)
+	}
+	result := &TransferResult{TxHash: client.createTxHash(extrinsic)}
+
+	ss, err := client.SubmitAndWatchContext(ctx, extrinsic)
+	if errors.Is(err, util.ErrNotSupportSubscribe) {
+		_, err = client.SubmitExtrinsicContext(ctx, extrinsic)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer ss.Unsubscribe()
+	for {
+		select {
+		case status, ok := <-ss.Statuses():
+			if !ok {
+				//订阅结束，错误在Err中
+				if err, ok := <-ss.Err(); ok {
+					return result, fmt.Errorf("watch transfer error,txHash=%s,err=%w", result.TxHash, err)
+				}
+				return result, fmt.Errorf("transfer status subscription closed,txHash=%s", result.TxHash)
+			}
+			switch status.Type {
+			case model.ExtrinsicInBlock, model.ExtrinsicFinalized:
+				result.BlockHash = status.Hash
+				return result, nil
+			case model.ExtrinsicUsurped, model.ExtrinsicDropped, model.ExtrinsicInvalid, model.ExtrinsicFinalityTimeout:
+				return result, fmt.Errorf("transfer failed,txHash=%s,status=%s", result.TxHash, status.Type)
+			}
+		case err, ok := <-ss.Err():
+			if !ok {
+				return result, fmt.Errorf("transfer status subscription closed,txHash=%s", result.TxHash)
+			}
+			return result, fmt.Errorf("watch transfer error,txHash=%s,err=%w", result.TxHash, err)
+		case <-ctx.Done():
+			return result, ctx.Err()
+		}
+	}
+}
+
+/*
+在线的机器生成未签名的转账交易，序列化后传到离线的机器签名
+交易的era为immortal，签名时使用的区块hash为genesis hash
+*/
+func (client *Client) PrepareTransfer(ctx context.Context, from, to, token string, amount uint64, memo string) (*tx.UnsignedTx, error) {
+	if tx.AddressToPublicKey(from) == "" {
+		return nil, fmt.Errorf("invalid from address %s", from)
+	}
+	if tx.AddressToPublicKey(to) == "" {
+		return nil, fmt.Errorf("invalid to address %s", to)
+	}
+	if err := client.checkToken(ctx, token); err != nil {
+		return nil, err
+	}
+	nonce, err := client.GetAccountNonceContext(ctx, from)
+	if err != nil {
+		return nil, fmt.Errorf("get account nonce error,err=%w", err)
+	}
+	genesisHash, err := client.GetGenesisHashContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	callId, err := client.transferCallId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	transaction := tx.CreateChainXTransaction(&tx.ChainXTransferParams{
+		From:   from,
+		To:     to,
+		Token:  token,
+		Amount: amount,
+		Nonce:  nonce,
+		Memo:   memo,
+	})
+	transaction.GenesisHash = util.RemoveHex0x(genesisHash)
+	transaction.SetBlockHashAndCallId(genesisHash, callId)
+	return transaction.UnsignedTx()
+}
+
+//最新metadata中XAssets.transfer的call id，metadata版本不支持时使用ChainX 1.0的tx.CallIdTransfer
+func (client *Client) transferCallId(ctx context.Context) (string, error) {
+	meta, err := client.loadMetadata(ctx, "")
+	if isMetadataUnavailable(err) {
+		return tx.CallIdTransfer, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return meta.CallIndex("XAssets", "transfer")
+}
+
+//获取genesis hash，第一次获取后保存在Client中
+func (client *Client) GetGenesisHash() (string, error) {
+	return client.GetGenesisHashContext(context.Background())
+}
+
+func (client *Client) GetGenesisHashContext(ctx context.Context) (string, error) {
+	client.mu.Lock()
+	genesisHash := client.genesisHash
+	client.mu.Unlock()
+	if genesisHash != "" {
+		return genesisHash, nil
+	}
+	respData, err := client.Rpc.CallContext(ctx, "chain_getBlockHash", []interface{}{0})
+	if err != nil {
+		return "", fmt.Errorf("get genesis hash error,err=%w", err)
+	}
+	if len(respData) == 0 {
+		return "", errors.New("get genesis hash error,block hash is empty")
+	}
+	genesisHash = string(respData)
+	client.mu.Lock()
+	client.genesisHash = genesisHash
+	client.mu.Unlock()
+	return genesisHash, nil
+}
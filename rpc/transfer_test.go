@@ -0,0 +1,62 @@
+package rpc
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"github.com/JFJun/chainX-go/model"
+	"github.com/JFJun/chainX-go/ss58"
+	"github.com/JFJun/chainX-go/util"
+	"strings"
+	"testing"
+)
+
+func TestPrepareTransferCallId(t *testing.T) {
+	pub, _ := hex.DecodeString("d43593c715fdd31c61141abd04a99fd6822c8558854ccde39a5684e7a56da27d")
+	from, _ := ss58.Encode(pub, ss58.ChainXPrefix)
+	to, _ := ss58.Encode(make([]byte, 32), ss58.ChainXPrefix)
+	//ChainX 1.0的nonce key
+	nonceKey := "0x5c54163a1c72509b5250f0a30b9001fdee9d9b48388b06921f1b210e81e3a1f0"
+	newMock := func(meta interface{}) *util.MockTransport {
+		mock := util.NewMockTransport()
+		mock.AddError("chainx_getAssets", []interface{}{0, assetPageSize}, &util.RPCError{Code: util.ErrCodeMethodNotFound, Message: "Method not found"})
+		mock.Add("chain_getBlockHash", []interface{}{0}, testBlockHash(0))
+		mock.Add("chain_getBlockHash", nil, testBlockHash(1))
+		mock.Add("state_getRuntimeVersion", nil, model.RuntimeVersion{SpecName: "chainx", SpecVersion: 1})
+		mock.Add("state_getRuntimeVersion", []interface{}{testBlockHash(1)}, model.RuntimeVersion{SpecName: "chainx", SpecVersion: 1})
+		if err, ok := meta.(error); ok {
+			mock.AddError("state_getMetadata", []interface{}{testBlockHash(1)}, err)
+		} else {
+			mock.Add("state_getMetadata", []interface{}{testBlockHash(1)}, meta)
+		}
+		mock.Add("state_getStorage", []interface{}{nonceKey}, "0x0300000000000000")
+		return mock
+	}
+
+	for _, test := range []struct {
+		metadata string
+		callId   string
+	}{
+		//运行时升级后XAssets.transfer为0800
+		{testFixture(t, "substrate_v12.hex"), "0800"},
+		{testFixture(t, "chainx_v7.hex"), "0803"},
+		//metadata版本不支持时使用ChainX 1.0的call id
+		{testMetadataV6, "0803"},
+	} {
+		client := NewWithTransport(newMock(test.metadata))
+		unsigned, err := client.PrepareTransfer(context.Background(), from, to, "PCX", 100, "memo")
+		if err != nil {
+			t.Errorf("call id %s: %v", test.callId, err)
+			continue
+		}
+		if !strings.HasPrefix(unsigned.Call, "0x"+test.callId) || unsigned.Nonce != 3 {
+			t.Errorf("call is %s nonce %d,expect call id %s", unsigned.Call, unsigned.Nonce, test.callId)
+		}
+	}
+
+	//其他错误直接返回
+	client := NewWithTransport(newMock(errors.New("connection refused")))
+	if _, err := client.PrepareTransfer(context.Background(), from, to, "PCX", 100, "memo"); err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Fatalf("prepare transfer error is %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+package tx
+
+import (
+	"encoding/hex"
+	"fmt"
+	codec "github.com/JFJun/chainX-go/codes"
+	"github.com/JFJun/chainX-go/metadata"
+)
+
+//Call 编码后的交易调用，call index加上按metadata参数类型编码的参数
+type Call struct {
+	Module    string
+	Name      string
+	CallIndex [2]byte
+	Args      []byte
+}
+
+/*
+根据metadata编码任意的交易调用，args的顺序和metadata中的参数一致
+例如 NewCall(meta, "XStaking", "nominate", to, uint64(100), "memo")
+参数的写法见codec.EncodeType，嵌套的交易(例如sudo)直接传入*Call
+*/
+func NewCall(meta *metadata.Metadata, module, call string, args ...interface{}) (*Call, error) {
+	c, err := meta.FindCall(module, call)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) != len(c.Args) {
+		return nil, fmt.Errorf("call %s.%s need %d args but got %d", c.Module, c.Name, len(c.Args), len(args))
+	}
+	result := &Call{Module: c.Module, Name: c.Name, CallIndex: c.Index}
+	for i, arg := range c.Args {
+		data, err := codec.EncodeType(arg.Type, args[i])
+		if err != nil {
+			return nil, fmt.Errorf("encode call %s.%s arg %s error,err=%v", c.Module, c.Name, arg.Name, err)
+		}
+		result.Args = append(result.Args, data...)
+	}
+	return result, nil
+}
+
+//使用已经编码好的call数据，例如CallIdTransfer和ChainXMethodTransfer
+func NewRawCall(callData []byte) (*Call, error) {
+	if len(callData) < 2 {
+		return nil, fmt.Errorf("call data length %d is too short", len(callData))
+	}
+	c := &Call{Args: callData[2:]}
+	copy(c.CallIndex[:], callData[:2])
+	return c, nil
+}
+
+//返回call index加上参数
+func (c *Call) Encode() []byte {
+	data := append([]byte{}, c.CallIndex[:]...)
+	return append(data, c.Args...)
+}
+
+func (c *Call) Hex() string {
+	return "0x" + hex.EncodeToString(c.Encode())
+}
+
+//实现codec.ScaleEncoder，作为sudo等交易的参数
+func (c *Call) ScaleBytes() ([]byte, error) {
+	return c.Encode(), nil
+}
@@ -0,0 +1,70 @@
+package tx
+
+import (
+	"encoding/hex"
+	"fmt"
+	codec "github.com/JFJun/chainX-go/codes"
+	"github.com/JFJun/chainX-go/metadata"
+)
+
+//DecodedCall 根据metadata解析出的交易调用
+type DecodedCall struct {
+	CallIndex string             `json:"call_index"`
+	Module    string             `json:"module"`
+	Call      string             `json:"call"`
+	Args      []codec.NamedValue `json:"args"`
+}
+
+/*
+根据metadata解析交易中的call数据，call数据为call index加上参数
+参数中嵌套的交易(例如sudo)也会解析为*DecodedCall
+参数类型不支持时返回已经解析出Module和Call的结果和错误
+*/
+func DecodeCall(meta *metadata.Metadata, callData []byte) (*DecodedCall, error) {
+	d := codec.NewTypeDecoder(callData)
+	d.CallDecoder = func(d *codec.TypeDecoder) (interface{}, error) {
+		return decodeCall(meta, d)
+	}
+	call, err := decodeCall(meta, d)
+	if err != nil {
+		return call, err
+	}
+	if d.Remaining() != 0 {
+		return call, fmt.Errorf("call %s.%s has %d extra bytes", call.Module, call.Call, d.Remaining())
+	}
+	return call, nil
+}
+
+func decodeCall(meta *metadata.Metadata, d *codec.TypeDecoder) (*DecodedCall, error) {
+	index, err := d.NextBytes(2)
+	if err != nil {
+		return nil, fmt.Errorf("decode call index error,err=%v", err)
+	}
+	c, err := meta.FindCallByIndex(index[0], index[1])
+	if err != nil {
+		return nil, err
+	}
+	call := &DecodedCall{
+		CallIndex: hex.EncodeToString(index),
+		Module:    c.Module,
+		Call:      c.Name,
+	}
+	for _, arg := range c.Args {
+		v, err := d.Decode(arg.Type)
+		if err != nil {
+			return call, fmt.Errorf("decode call %s.%s arg %s error,err=%v", c.Module, c.Name, arg.Name, err)
+		}
+		call.Args = append(call.Args, codec.NamedValue{Name: arg.Name, Type: arg.Type, Value: v})
+	}
+	return call, nil
+}
+
+//按metadata解析交易的call，结果保存在Call中，需要先调用ParseChainXExtrinsic
+func (ce *ChainXExtrinsic) DecodeCall(meta *metadata.Metadata) error {
+	if len(ce.callData) == 0 {
+		return fmt.Errorf("extrinsic call data is empty")
+	}
+	call, err := DecodeCall(meta, ce.callData)
+	ce.Call = call
+	return err
+}
@@ -3,11 +3,9 @@ package tx
 import (
 	"encoding/hex"
 	"errors"
-	codec "github.com/JFJun/chainX-go/codes"
+	"fmt"
 	"github.com/JFJun/chainX-go/ss58"
 	"strings"
-
-	"golang.org/x/crypto/ed25519"
 )
 
 /*
@@ -29,6 +27,7 @@ type ChainXTransaction struct {
 	//BlockHeight        uint64 `json:"block_height"`     //最新区块高度
 	BlockHash   string `json:"block_hash"`   //最新区块hash
 	GenesisHash string `json:"genesis_hash"` //
+	Era         Era    `json:"era"`          //默认为immortal，mortal时BlockHash为Era.Birth(checkpoint)区块的hash
 	//SpecVersion        uint32 `json:"spec_version"`
 	//TransactionVersion uint32 `json:"transaction_version"`
 	Token  string `json:"token"`
@@ -71,126 +70,90 @@ func (t *ChainXTransaction) CreatSignData() (string, error) {
 	return tp.Encode(), nil
 }
 
+//签名的数据，Signed为CreatSignData返回的数据
+func (t *ChainXTransaction) SigningPayload() (*SigningPayload, error) {
+	b, err := t.builder()
+	if err != nil {
+		return nil, err
+	}
+	return b.SigningPayload()
+}
+
 func (t *ChainXTransaction) Sign(private, message string) (string, error) {
 	message = Remove0X(message)
 	messageBytes, err := hex.DecodeString(message)
 	if err != nil {
 		return "", err
 	}
-	private = Remove0X(private)
-	priv, err1 := hex.DecodeString(private)
+	//使用ed25519签名
+	signer, err1 := NewEd25519Signer(private)
 	if err1 != nil {
 		return "", err1
 	}
-	//使用ed25519签名
-	privKey := ed25519.NewKeyFromSeed(priv)
-	sig := ed25519.Sign(privKey, messageBytes)
-	if len(sig) != 64 {
-		return "", errors.New("sign fail,sig length is not equal 64")
+	sig, err := signer.Sign(messageBytes)
+	if err != nil {
+		return "", err
 	}
 	return hex.EncodeToString(sig), nil
 }
 
+/*
+使用Signer签名并组装交易，返回可以直接提交的交易hex
+Signer的公钥必须和SenderPubkey一致
+*/
+func (t *ChainXTransaction) SignWith(signer Signer) (string, error) {
+	if hex.EncodeToString(signer.PublicKey()) != Remove0X(t.SenderPubkey) {
+		return "", errors.New("signer public key is not equal sender public key")
+	}
+	b, err := t.builder()
+	if err != nil {
+		return "", err
+	}
+	return b.SignWith(signer)
+}
+
 func (t *ChainXTransaction) CombineChainXtx(signature string) (string, error) {
-	signed := make([]byte, 0)
-	//签名版本号
-	signed = append(signed, SigningChainXBit)
-	signed = append(signed, 0xff)
 	//from地址
-	from, err := hex.DecodeString(t.SenderPubkey)
-
+	from, err := hex.DecodeString(Remove0X(t.SenderPubkey))
 	if err != nil || len(from) != 32 {
-		return "", nil
+		return "", errors.New("invalid sender public key")
 	}
-	signed = append(signed, from...)
-
 	//签名数据
-	//signed = append(signed, 0x01) // ed25519: 0x00 sr25519: 0x01
-	signature = Remove0X(signature)
-	sig, err := hex.DecodeString(signature)
+	sig, err := hex.DecodeString(Remove0X(signature))
 	if err != nil || len(sig) != 64 {
-		return "", nil
-	}
-	signed = append(signed, sig...)
-
-	//nonce
-	if t.Nonce == 0 {
-		signed = append(signed, []byte{0}...)
-	} else {
-		nonce, err := codec.Encode(Compact_U32, uint64(t.Nonce))
-		if err != nil {
-			return "", err
-		}
-		nonceBytes, _ := hex.DecodeString(nonce)
-		signed = append(signed, nonceBytes...)
-	}
-	// era
-	signed = append(signed, []byte{0x00}...)
-
-	//acceleration
-	acceleration, err := codec.Encode(Compact_U32, t.Acceleration)
-	if err != nil {
-		return "", err
+		return "", errors.New("invalid signature")
 	}
-	accBytes, _ := hex.DecodeString(acceleration)
-	signed = append(signed, accBytes...)
-
-	method, err := NewChainXMethodTransfer(t.RecipientPubkey, t.Token, t.Memo, t.Amount)
+	b, err := t.builder()
 	if err != nil {
 		return "", err
 	}
+	return b.Combine(from, sig)
+}
 
-	methodBytes := method.Encode(t.CallId)
-
-	signed = append(signed, methodBytes...)
-
-	length, err := codec.Encode(Compact_U32, uint64(len(signed)))
-
+func (t *ChainXTransaction) newSignData() (*ChainXSignaturePayload, error) {
+	b, err := t.builder()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	lengthBytes, _ := hex.DecodeString(length)
-
-	lengthBytes[0] += 1
-
-	return "0x" + hex.EncodeToString(lengthBytes) + hex.EncodeToString(signed), nil
+	return b.SignaturePayload()
 }
 
-func (t *ChainXTransaction) newSignData() (*ChainXSignaturePayload, error) {
-	tp := new(ChainXSignaturePayload)
-	if t.Nonce == 0 {
-		tp.Nonce = []byte{0}
-	} else {
-		nonce, err := codec.Encode(Compact_U32, uint64(t.Nonce))
-		if err != nil {
-			return nil, err
-		}
-		tp.Nonce, _ = hex.DecodeString(nonce)
-	}
-	//method
+//转账交易的call由ChainXMethodTransfer编码，再使用ExtrinsicBuilder组装
+func (t *ChainXTransaction) builder() (*ExtrinsicBuilder, error) {
 	method, err := NewChainXMethodTransfer(t.RecipientPubkey, t.Token, t.Memo, t.Amount)
 	if err != nil {
 		return nil, err
 	}
-	tp.Method = method.Encode(t.CallId)
-
-	//era
-	tp.Era = []byte{0x00}
-
-	//blockHash
-	block, err := hex.DecodeString(Remove0X(t.BlockHash))
-	if err != nil || len(block) != 32 {
-		return nil, errors.New("invalid block hash")
-	}
-	tp.BlockHash = block
-	// acceleration
-	acceleration, err := codec.Encode(Compact_U32, t.Acceleration)
+	call, err := NewRawCall(method.Encode(t.CallId))
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("invalid call id %s", t.CallId)
 	}
-	tp.Acceleration, _ = hex.DecodeString(acceleration)
-	return tp, nil
+	b := NewExtrinsicBuilder(call, t.Nonce, t.BlockHash)
+	b.Acceleration = t.Acceleration
+	b.Era = t.Era
+	return b, nil
 }
+
 func AddressToPublicKey(address string) string {
 	if address == "" {
 		return ""
@@ -2,8 +2,12 @@ package tx
 
 import (
 	"encoding/hex"
+	"golang.org/x/crypto/blake2b"
 )
 
+//签名数据超过这个长度时，签名的是数据的blake2-256
+const maxUnhashedPayloadLength = 256
+
 type ChainXSignaturePayload struct {
 	Nonce        []byte
 	Method       []byte
@@ -12,14 +16,45 @@ type ChainXSignaturePayload struct {
 	Acceleration []byte
 }
 
+//实际需要签名的数据的hex，超过256字节时为blake2-256
 func (t ChainXSignaturePayload) Encode() string {
+	return t.SigningPayload().Hex()
+}
+
+func (t ChainXSignaturePayload) SigningPayload() *SigningPayload {
 	payload := make([]byte, 0)
 	payload = append(payload, t.Nonce...)
 	payload = append(payload, t.Method...)
 	payload = append(payload, t.Era...)
 	payload = append(payload, t.BlockHash...)
 	payload = append(payload, t.Acceleration...)
-	//todo if len big than 256 ,use blake2b to hash it
+	return NewSigningPayload(payload)
+}
+
+/*
+SigningPayload 签名的数据
+Raw为 nonce + call + era + blockHash + acceleration 编码后的数据
+Signed为实际签名的数据，和substrate一致，Raw超过256字节时为Raw的blake2-256，否则和Raw相同
+*/
+type SigningPayload struct {
+	Raw    []byte `json:"raw"`
+	Signed []byte `json:"signed"`
+}
+
+func NewSigningPayload(raw []byte) *SigningPayload {
+	p := &SigningPayload{Raw: raw, Signed: raw}
+	if len(raw) > maxUnhashedPayloadLength {
+		h := blake2b.Sum256(raw)
+		p.Signed = h[:]
+	}
+	return p
+}
+
+func (p *SigningPayload) IsHashed() bool {
+	return len(p.Raw) > maxUnhashedPayloadLength
+}
 
-	return hex.EncodeToString(payload)
+//Signed的hex
+func (p *SigningPayload) Hex() string {
+	return hex.EncodeToString(p.Signed)
 }
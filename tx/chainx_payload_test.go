@@ -0,0 +1,73 @@
+package tx
+
+import (
+	"bytes"
+	"encoding/hex"
+	"golang.org/x/crypto/blake2b"
+	"testing"
+)
+
+func TestSigningPayloadNotHashed(t *testing.T) {
+	raw := bytes.Repeat([]byte{0x01}, maxUnhashedPayloadLength)
+	p := NewSigningPayload(raw)
+	if p.IsHashed() {
+		t.Fatal("256 bytes payload should not be hashed")
+	}
+	if !bytes.Equal(p.Raw, raw) || !bytes.Equal(p.Signed, raw) {
+		t.Fatal("signed payload should be equal to raw")
+	}
+	if p.Hex() != hex.EncodeToString(raw) {
+		t.Fatalf("hex is %s", p.Hex())
+	}
+}
+
+func TestSigningPayloadHashed(t *testing.T) {
+	raw := bytes.Repeat([]byte{0x01}, maxUnhashedPayloadLength+1)
+	p := NewSigningPayload(raw)
+	if !p.IsHashed() {
+		t.Fatal("257 bytes payload should be hashed")
+	}
+	if !bytes.Equal(p.Raw, raw) {
+		t.Fatal("raw payload should not be changed")
+	}
+	h := blake2b.Sum256(raw)
+	if !bytes.Equal(p.Signed, h[:]) {
+		t.Fatalf("signed payload is %x,expect blake2-256 %x", p.Signed, h)
+	}
+}
+
+//nonce,era和acceleration各1个字节，blockHash 32个字节，call index 2个字节
+func builderWithPayloadLength(t *testing.T, length int) *ExtrinsicBuilder {
+	call, err := NewRawCall(append([]byte{0x08, 0x03}, make([]byte, length-37)...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewExtrinsicBuilder(call, 0, hex.EncodeToString(make([]byte, 32)))
+}
+
+func TestBuilderSigningPayload(t *testing.T) {
+	for _, length := range []int{maxUnhashedPayloadLength, maxUnhashedPayloadLength + 1} {
+		b := builderWithPayloadLength(t, length)
+		p, err := b.SigningPayload()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(p.Raw) != length {
+			t.Fatalf("raw payload length is %d,expect %d", len(p.Raw), length)
+		}
+		hashed := length > maxUnhashedPayloadLength
+		if p.IsHashed() != hashed {
+			t.Fatalf("length %d: hashed is %v", length, p.IsHashed())
+		}
+		if hashed && len(p.Signed) != 32 || !hashed && !bytes.Equal(p.Signed, p.Raw) {
+			t.Fatalf("length %d: signed payload is %x", length, p.Signed)
+		}
+		sp, err := b.SignaturePayload()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sp.Encode() != p.Hex() {
+			t.Fatalf("length %d: signature payload is %s,expect %s", length, sp.Encode(), p.Hex())
+		}
+	}
+}
@@ -43,33 +43,20 @@ func NewChainXMethodTransfer(pubkey, token, memo string, amount uint64) (*ChainX
 	}
 	tokenBytes, _ := hex.DecodeString(tk)
 
-	//编码 token长度 一般token的长度不会大于256*4,所以使用 int直接转换为[]byte
-	tokenLength := []byte{byte(len(tokenBytes) * 4)}
+	//编码 token长度
+	tokenLength := codec.CompactBytes(uint64(len(tokenBytes)))
 
 	//编码memo
-	var (
-		memoBytes    []byte
-		memoLenBytes []byte
-	)
+	var memoBytes []byte
 	if memo != "" {
 		mm, err := codec.Encode("string", memo)
 		if err != nil {
-			return nil, fmt.Errorf("encode token error,err=%v", err)
+			return nil, fmt.Errorf("encode memo error,err=%v", err)
 		}
-
-		//编码memo的长度
 		memoBytes, _ = hex.DecodeString(mm)
-		mLen := len(memoBytes) * 4
-		if mLen < 256 {
-			memoLenBytes = []byte{byte(mLen)}
-		} else {
-			lenBuf := make([]byte, 2)
-			binary.LittleEndian.PutUint16(lenBuf, uint16(mLen))
-			memoLenBytes = lenBuf
-		}
-	} else {
-		memoLenBytes = []byte{0}
 	}
+	//编码memo的长度
+	memoLenBytes := codec.CompactBytes(uint64(len(memoBytes)))
 	return &ChainXMethodTransfer{
 		DestPubkey:  pubBytes,
 		Token:       tokenBytes,
@@ -0,0 +1,113 @@
+package tx
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+//mortal era的period范围
+const (
+	MinEraPeriod = 4
+	MaxEraPeriod = 1 << 16
+)
+
+/*
+Era 交易的有效期
+immortal的交易一直有效，签名使用genesis hash，编码为0x00
+mortal的交易在birth到death之间的区块中有效，签名使用birth区块的hash，编码为两个字节
+*/
+type Era struct {
+	IsMortal bool   `json:"is_mortal"`
+	Period   uint64 `json:"period,omitempty"`
+	Phase    uint64 `json:"phase,omitempty"`
+}
+
+func ImmortalEra() Era {
+	return Era{}
+}
+
+/*
+根据checkpoint区块的高度生成mortal era，有效期为period个区块
+period必须是[4, 65536]之间的2的幂次，否则返回错误
+period大于4096时phase按照编码的精度取整，交易的birth区块Birth(checkpoint)可能早于checkpoint
+签名使用的区块hash必须是Birth(checkpoint)区块的hash
+*/
+func NewMortalEra(checkpoint, period uint64) (Era, error) {
+	if period < MinEraPeriod || period > MaxEraPeriod || period&(period-1) != 0 {
+		return Era{}, fmt.Errorf("invalid era period %d,must be power of 2 in [%d, %d]", period, MinEraPeriod, MaxEraPeriod)
+	}
+	phase := checkpoint % period
+	quantizeFactor := eraQuantizeFactor(period)
+	return Era{IsMortal: true, Period: period, Phase: phase / quantizeFactor * quantizeFactor}, nil
+}
+
+func eraQuantizeFactor(period uint64) uint64 {
+	if factor := period >> 12; factor > 1 {
+		return factor
+	}
+	return 1
+}
+
+func (e Era) Encode() []byte {
+	if !e.IsMortal {
+		return []byte{0x00}
+	}
+	trailingZeros := uint64(bits.TrailingZeros64(e.Period)) - 1
+	if trailingZeros < 1 {
+		trailingZeros = 1
+	}
+	if trailingZeros > 15 {
+		trailingZeros = 15
+	}
+	encoded := trailingZeros | (e.Phase/eraQuantizeFactor(e.Period))<<4
+	data := make([]byte, 2)
+	binary.LittleEndian.PutUint16(data, uint16(encoded))
+	return data
+}
+
+func (e Era) Hex() string {
+	return hex.EncodeToString(e.Encode())
+}
+
+//从data的开头解析Era，返回解析使用的字节数
+func DecodeEra(data []byte) (Era, int, error) {
+	if len(data) == 0 {
+		return Era{}, 0, errors.New("era is empty")
+	}
+	if data[0] == 0x00 {
+		return ImmortalEra(), 1, nil
+	}
+	if len(data) < 2 {
+		return Era{}, 0, errors.New("mortal era need 2 bytes")
+	}
+	encoded := uint64(binary.LittleEndian.Uint16(data[:2]))
+	period := uint64(2) << (encoded % 16)
+	phase := (encoded >> 4) * eraQuantizeFactor(period)
+	if period < MinEraPeriod || phase >= period {
+		return Era{}, 0, fmt.Errorf("invalid mortal era %x", data[:2])
+	}
+	return Era{IsMortal: true, Period: period, Phase: phase}, 2, nil
+}
+
+//交易开始有效的区块高度，current为交易所在或者将要打包的区块高度，immortal为0
+func (e Era) Birth(current uint64) uint64 {
+	if !e.IsMortal {
+		return 0
+	}
+	if current < e.Phase {
+		current = e.Phase
+	}
+	return (current-e.Phase)/e.Period*e.Period + e.Phase
+}
+
+//交易失效的区块高度，immortal为math.MaxUint64
+func (e Era) Death(current uint64) uint64 {
+	if !e.IsMortal {
+		return math.MaxUint64
+	}
+	return e.Birth(current) + e.Period
+}
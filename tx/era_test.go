@@ -0,0 +1,82 @@
+package tx
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestNewMortalEraInvalidPeriod(t *testing.T) {
+	for _, period := range []uint64{0, 2, 3, 5, 100, 4097, MaxEraPeriod * 2} {
+		if _, err := NewMortalEra(100, period); err == nil {
+			t.Errorf("period %d: expect error", period)
+		}
+	}
+}
+
+func TestEraEncode(t *testing.T) {
+	tests := []struct {
+		checkpoint uint64
+		period     uint64
+		phase      uint64
+		encoded    []byte
+	}{
+		{checkpoint: 42, period: 64, phase: 42, encoded: []byte{0xa5, 0x02}},
+		{checkpoint: 20000, period: 32768, phase: 20000, encoded: []byte{0x4e, 0x9c}},
+		//period大于4096时phase按精度取整
+		{checkpoint: 10001, period: 8192, phase: 1808, encoded: []byte{0x8c, 0x38}},
+		{checkpoint: 7, period: 4, phase: 3, encoded: []byte{0x31, 0x00}},
+	}
+	for _, test := range tests {
+		era, err := NewMortalEra(test.checkpoint, test.period)
+		if err != nil {
+			t.Fatalf("checkpoint %d period %d: %v", test.checkpoint, test.period, err)
+		}
+		if era.Period != test.period || era.Phase != test.phase {
+			t.Errorf("checkpoint %d period %d: got period %d phase %d", test.checkpoint, test.period, era.Period, era.Phase)
+		}
+		if !bytes.Equal(era.Encode(), test.encoded) {
+			t.Errorf("checkpoint %d period %d: encoded %x,expect %x", test.checkpoint, test.period, era.Encode(), test.encoded)
+		}
+		decoded, n, err := DecodeEra(era.Encode())
+		if err != nil || n != 2 || decoded != era {
+			t.Errorf("checkpoint %d period %d: decoded %+v,%d,%v", test.checkpoint, test.period, decoded, n, err)
+		}
+	}
+}
+
+func TestEraBirthDeath(t *testing.T) {
+	era, _ := NewMortalEra(42, 64)
+	for _, current := range []uint64{42, 60, 105} {
+		if birth := era.Birth(current); birth != 42 {
+			t.Errorf("current %d: birth %d,expect 42", current, birth)
+		}
+		if death := era.Death(current); death != 106 {
+			t.Errorf("current %d: death %d,expect 106", current, death)
+		}
+	}
+	if birth := era.Birth(106); birth != 106 {
+		t.Errorf("birth %d,expect 106", birth)
+	}
+	//phase取整后birth区块早于checkpoint，签名需要使用birth区块的hash
+	era, _ = NewMortalEra(10001, 8192)
+	if birth := era.Birth(10001); birth != 10000 {
+		t.Errorf("birth %d,expect 10000", birth)
+	}
+	immortal := ImmortalEra()
+	if immortal.Birth(100) != 0 || immortal.Death(100) != math.MaxUint64 {
+		t.Errorf("immortal birth %d death %d", immortal.Birth(100), immortal.Death(100))
+	}
+}
+
+func TestDecodeEra(t *testing.T) {
+	era, n, err := DecodeEra([]byte{0x00, 0x01})
+	if err != nil || n != 1 || era.IsMortal {
+		t.Errorf("immortal: %+v,%d,%v", era, n, err)
+	}
+	for _, data := range [][]byte{nil, {0x01}, {0xf0, 0xff}} {
+		if _, _, err := DecodeEra(data); err == nil {
+			t.Errorf("%x: expect error", data)
+		}
+	}
+}
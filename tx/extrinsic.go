@@ -1,220 +1,160 @@
 package tx
 
 import (
-	"encoding/binary"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	codec "github.com/JFJun/chainX-go/codes"
+	"github.com/JFJun/chainX-go/metadata"
 	"github.com/JFJun/chainX-go/ss58"
-	"github.com/JFJun/chainX-go/util"
-	"strconv"
 )
 
+//签名者是账户索引时，需要先查询索引对应的AccountId才能验证签名
+var ErrAccountIndexSigner = errors.New("account index signer needs lookup")
+
 type ChainXExtrinsic struct {
-	offset        int
-	rawData       []byte
-	data          []byte
-	compactLength int
-	CallIndex     string
-	From          string
-	To            string
-	Signature     string
-	Nonce         uint64
-	Era           string
-	Acceleration  int
-	Token         string
-	Amount        uint64
-	Memo          string
-	Timestamp     int64
+	data         []byte
+	CallIndex    string
+	From         string //账户索引时为十进制的索引
+	To           string
+	Signature    string
+	Nonce        uint64
+	Era          Era
+	Acceleration int
+	Token        string
+	Amount       uint64
+	Memo         string
+	Timestamp    int64
+	Call         *DecodedCall      //调用DecodeCall之后才有
+	Decoded      *DecodedExtrinsic //调用ParseChainXExtrinsic之后才有
+	callData     []byte
+	meta         *metadata.Metadata //SetMetadata设置，用来查找转账和时间戳的call id
 }
 
 func NewChainXExtrinsic(data []byte) *ChainXExtrinsic {
 	ce := new(ChainXExtrinsic)
 	ce.data = data
-	ce.offset = 0
 	return ce
 }
-func (ce *ChainXExtrinsic) getNextBytes(length int) []byte {
-	if ce.offset+length > len(ce.data) {
-		data := ce.data[ce.offset:]
-		ce.offset = len(ce.data)
-		return data
-	}
-	data := ce.data[ce.offset : ce.offset+length]
-	ce.offset = ce.offset + length
-	return data
+
+/*
+使用metadata中XAssets.transfer和Timestamp.set的call id解析转账和时间戳，需要在ParseChainXExtrinsic之前调用
+没有设置时使用ChainX 1.0的CallIdTransfer和CallIdTimestamp，metadata中没有的call不解析
+*/
+func (ce *ChainXExtrinsic) SetMetadata(meta *metadata.Metadata) {
+	ce.meta = meta
 }
 
-func (ce *ChainXExtrinsic) processCompactBytes() []byte {
-	// 初始化
-	ce.compactLength = 0
-	compactByte := ce.getNextBytes(1)
-	var byteMod = 0
-	if len(compactByte) != 0 {
-		byteMod = int(compactByte[0]) % 4
+//转账和时间戳的call id
+func (ce *ChainXExtrinsic) callIds() (transfer, timestamp string) {
+	if ce.meta == nil {
+		return CallIdTransfer, CallIdTimestamp
 	}
-	if byteMod == 0 {
-		ce.compactLength = 1
-	} else if byteMod == 1 {
-		ce.compactLength = 2
-	} else if byteMod == 2 {
-		ce.compactLength = 4
-	} else {
-		ce.compactLength = 5 + ((int(compactByte[0]) - 3) / 4)
-	}
-	var CompactBytes []byte
-	if ce.compactLength == 1 {
-		CompactBytes = compactByte
-	} else if util.IntInSlice(ce.compactLength, []int{2, 4}) {
-		CompactBytes = append(compactByte[:], ce.getNextBytes(ce.compactLength - 1)[:]...)
-	} else {
-		CompactBytes = ce.getNextBytes(ce.compactLength - 1)
-	}
-	return CompactBytes
+	transfer, _ = ce.meta.CallIndex("XAssets", "transfer")
+	timestamp, _ = ce.meta.CallIndex("Timestamp", "set")
+	return transfer, timestamp
 }
 
+/*
+使用DecodeExtrinsic解析交易，转账和时间戳交易会解析出To，Token，Amount，Memo和Timestamp
+其他的call只保留CallIndex和原始数据，需要调用DecodeCall按metadata解析
+*/
 func (ce *ChainXExtrinsic) ParseChainXExtrinsic() error {
-	compactBytes := ce.processCompactBytes()
-
-	length, err := codec.DecodeCompact32(compactBytes)
-
+	ext, err := DecodeExtrinsic(ce.data)
 	if err != nil {
-		return fmt.Errorf("parse extrinsic length to  compact32 error,err=%v", err)
-	}
-
-	l := int(length)
-	if l != len(ce.data[ce.compactLength:]) {
-		return errors.New("extrinsic length is not equal")
+		return err
 	}
-
-	versionInfo := util.BytesToHex(ce.getNextBytes(1))
-
-	containsTx := util.HexToU256(versionInfo).Int64() >= 80
-	if versionInfo == "01" || versionInfo == "81" {
-		if containsTx {
-			//解析from地址
-			var (
-				err error
-			)
-			ce.From, err = ce.parseAddress()
-
-			if err != nil {
-				return fmt.Errorf("get from address error,err=%v", err)
-			}
-			//解析签名
-			ce.Signature = util.BytesToHex(ce.getNextBytes(64))
-
-			//解析nonce
-			nonceCompactBytes := ce.processCompactBytes()
-
-			nonce, err := codec.DecodeCompact32(nonceCompactBytes)
-			if err != nil {
-				return fmt.Errorf("parse nonce length to  compact32 error,err=%v", err)
-			}
-			ce.Nonce = uint64(nonce)
-
-			//解析 era
-			era := util.BytesToHex(ce.getNextBytes(1))
-			if era != "00" {
-				return errors.New("era is not equal '00'")
-			}
-			// 	解析 acceleration
-
-			acc, err := codec.DecodeCompact32(ce.processCompactBytes())
-			if err != nil {
-				return fmt.Errorf("decode acceleration error,err=%v", err)
-			}
-			ce.Acceleration = int(acc)
-
-		}
-
-		ce.CallIndex = util.BytesToHex(ce.getNextBytes(2))
-	} else {
-		return fmt.Errorf("Extrinsic version %s is not support", versionInfo)
-	}
-	if ce.CallIndex != "" {
-		return ce.parseCallIndex()
-
+	ce.Decoded = ext
+	if ext.Signed {
+		ce.From = ext.Signer.String()
+		ce.Signature = ext.Signature
+		ce.Nonce = ext.Nonce
+		ce.Era = ext.Era
+		ce.Acceleration = int(ext.Acceleration)
 	}
-	return nil
+	ce.callData = ext.CallData
+	ce.CallIndex = ext.CallIndex
+	return ce.parseCallIndex()
 }
 
-func (ce *ChainXExtrinsic) parseAddress() (string, error) {
-	al := ce.getNextBytes(1)
-	AccountLength := util.BytesToHex(al)
-	var (
-		address string
-		err     error
-	)
-	if AccountLength == "ff" {
-		address, err = ss58.EncodeByPubHex(util.BytesToHex(ce.getNextBytes(32)), ss58.ChainXPrefix)
-	} else {
-		num, _ := strconv.ParseUint(AccountLength, 16, 32)
-		address = fmt.Sprintf("%d", num)
-		//address, err = ss58.EncodeByPubHex(util.BytesToHex(append(al, ce.getNextBytes(31)...)), ss58.ChainXPrefix)
-	}
-	if err != nil {
-		return "", fmt.Errorf("parse address error,err=%v", err)
+//转换为可以修改和重新编码的Extrinsic
+func (ce *ChainXExtrinsic) Extrinsic() (*Extrinsic, error) {
+	e := new(Extrinsic)
+	if err := e.UnmarshalSCALE(ce.data); err != nil {
+		return nil, err
 	}
-	return address, nil
+	return e, nil
 }
 
 func (ce *ChainXExtrinsic) parseCallIndex() error {
-	var (
-		err error
-	)
-	if ce.CallIndex == CallIdTransfer {
+	d := codec.NewTypeDecoder(ce.callData[2:])
+	transfer, timestamp := ce.callIds()
+	switch ce.CallIndex {
+	case transfer:
 		//	表示为 一笔交易
-		ce.To, err = ce.parseAddress()
+		to, err := decodeAddress(d)
 		if err != nil {
 			return fmt.Errorf("parse to address error,err=%v", err)
 		}
-		//解析token长度
-		tl := ce.processCompactBytes()
-		tLen, err := codec.DecodeCompact32(tl)
+		ce.To = to.String()
+		token, err := d.Decode("Token")
 		if err != nil {
-			return fmt.Errorf("parse token length error,err=%v", err)
+			return fmt.Errorf("parse token error,err=%v", err)
 		}
-		tLength := int(tLen)
-		//解析token
-
-		t := ce.getNextBytes(tLength)
-
-		ce.Token = string(t)
-		//解析Amount
-		ab := ce.getNextBytes(8)
-		ce.Amount = binary.LittleEndian.Uint64(ab)
-		//	解析memo
-		ml := ce.processCompactBytes()
-		if ml == nil || len(ml) == 0 {
-			//  memo is null
-			ce.Memo = ""
-		} else {
-			mLen, err := codec.DecodeCompact32(ml)
-			if err != nil {
-				return fmt.Errorf("decode memo length error,err=%v", err)
-			}
-			mLength := int(mLen)
-			if mLength == 0 {
-				ce.Memo = ""
-			} else {
-				ce.Memo = string(ce.getNextBytes(mLength))
-			}
-
+		ce.Token = token.(string)
+		amount, err := d.Decode("u64")
+		if err != nil {
+			return fmt.Errorf("parse amount error,err=%v", err)
 		}
-
-	} else if ce.CallIndex == CallIdTimestamp {
-		t := util.BytesToHex(ce.getNextBytes(1))
-		if t == "03" {
-			//解析时间戳
-			tu, err := codec.DecodeUint64(ce.getNextBytes(4))
-			if err != nil {
-				return fmt.Errorf("parse timestamp error,err=%v", err)
-			}
-			ce.Timestamp = int64(tu)
+		ce.Amount = amount.(uint64)
+		memo, err := d.Decode("Memo")
+		if err != nil {
+			return fmt.Errorf("parse memo error,err=%v", err)
+		}
+		ce.Memo = memo.(string)
+	case timestamp:
+		//解析时间戳
+		t, err := decodeCompactUint64(d)
+		if err != nil {
+			return fmt.Errorf("parse timestamp error,err=%v", err)
 		}
-	} else if ce.CallIndex == CallIdProduce {
+		ce.Timestamp = int64(t)
+	default:
 		return nil
 	}
+	if d.Remaining() != 0 {
+		return fmt.Errorf("call %s has %d extra bytes", ce.CallIndex, d.Remaining())
+	}
 	return nil
 }
+
+/*
+验证已签名交易的签名，blockHash为签名时使用的区块hash，era为immortal时是genesis hash，mortal时是birth区块的hash
+sr25519和ed25519签名都支持，签名者是账户索引时返回ErrAccountIndexSigner
+*/
+func (ce *ChainXExtrinsic) VerifySignature(blockHash string) (bool, error) {
+	if ce.Signature == "" {
+		return false, errors.New("extrinsic is not signed")
+	}
+	if ce.Decoded != nil && ce.Decoded.Signer.IsIndex {
+		return false, fmt.Errorf("signer is account index %d,%w", ce.Decoded.Signer.Index, ErrAccountIndexSigner)
+	}
+	pub, err := ss58.DecodeToPub(ce.From)
+	if err != nil {
+		return false, fmt.Errorf("decode from address error,err=%v", err)
+	}
+	sig, err := hex.DecodeString(ce.Signature)
+	if err != nil {
+		return false, fmt.Errorf("decode signature error,err=%v", err)
+	}
+	tp := new(ChainXSignaturePayload)
+	tp.Nonce = codec.CompactBytes(ce.Nonce)
+	tp.Method = ce.callData
+	tp.Era = ce.Era.Encode()
+	tp.BlockHash, err = hex.DecodeString(Remove0X(blockHash))
+	if err != nil || len(tp.BlockHash) != 32 {
+		return false, errors.New("invalid block hash")
+	}
+	tp.Acceleration = codec.CompactBytes(uint64(ce.Acceleration))
+	return VerifySignature(tp.SigningPayload().Signed, sig, pub), nil
+}
@@ -0,0 +1,135 @@
+package tx
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	codec "github.com/JFJun/chainX-go/codes"
+)
+
+const UnsignedChainXBit = byte(0x01)
+
+/*
+ExtrinsicBuilder 把任意的Call组装为ChainX的交易
+签名交易的格式：
+	长度 + 0x81 + 0xff + 发送者公钥 + 签名 + nonce + era + acceleration + call
+	era为immortal时为0x00，mortal时为两个字节
+签名的数据：
+	nonce + call + era + blockHash + acceleration，超过256字节时签名它的blake2-256
+*/
+type ExtrinsicBuilder struct {
+	Call         *Call
+	Nonce        uint64
+	Acceleration uint64
+	Era          Era    //默认为immortal
+	BlockHash    string //签名使用的区块hash，era为immortal时为genesis hash，mortal时为checkpoint区块的hash
+}
+
+//Acceleration默认为1，Era默认为immortal
+func NewExtrinsicBuilder(call *Call, nonce uint64, blockHash string) *ExtrinsicBuilder {
+	return &ExtrinsicBuilder{
+		Call:         call,
+		Nonce:        nonce,
+		Acceleration: 1,
+		BlockHash:    blockHash,
+	}
+}
+
+/*
+设置交易为mortal，era一般由NewMortalEra(最新的区块高度, period)生成
+birthHash为era.Birth(checkpoint)区块的hash，作为签名使用的区块hash，节点按birth区块的hash验证签名
+period大于4096时birth区块可能早于checkpoint，不能直接使用checkpoint区块的hash
+*/
+func (b *ExtrinsicBuilder) SetMortal(era Era, birthHash string) {
+	b.Era = era
+	b.BlockHash = birthHash
+}
+
+func (b *ExtrinsicBuilder) SignaturePayload() (*ChainXSignaturePayload, error) {
+	if b.Call == nil {
+		return nil, errors.New("extrinsic call is nil")
+	}
+	block, err := hex.DecodeString(Remove0X(b.BlockHash))
+	if err != nil || len(block) != 32 {
+		return nil, errors.New("invalid block hash")
+	}
+	return &ChainXSignaturePayload{
+		Nonce:        codec.CompactBytes(b.Nonce),
+		Method:       b.Call.Encode(),
+		Era:          b.Era.Encode(),
+		BlockHash:    block,
+		Acceleration: codec.CompactBytes(b.Acceleration),
+	}, nil
+}
+
+//签名的数据，包括编码后的原始数据和实际签名的数据，可以用于记录日志
+func (b *ExtrinsicBuilder) SigningPayload() (*SigningPayload, error) {
+	tp, err := b.SignaturePayload()
+	if err != nil {
+		return nil, err
+	}
+	return tp.SigningPayload(), nil
+}
+
+//需要签名的数据，超过256字节时为blake2-256
+func (b *ExtrinsicBuilder) SignData() ([]byte, error) {
+	p, err := b.SigningPayload()
+	if err != nil {
+		return nil, err
+	}
+	return p.Signed, nil
+}
+
+//组装签名交易，返回0x开头的hex
+func (b *ExtrinsicBuilder) Combine(senderPubkey, signature []byte) (string, error) {
+	e, err := b.Build(senderPubkey, signature)
+	if err != nil {
+		return "", err
+	}
+	return e.Hex()
+}
+
+//组装签名交易
+func (b *ExtrinsicBuilder) Build(senderPubkey, signature []byte) (*Extrinsic, error) {
+	if b.Call == nil {
+		return nil, errors.New("extrinsic call is nil")
+	}
+	if len(senderPubkey) != 32 {
+		return nil, errors.New("invalid sender public key")
+	}
+	if len(signature) != 64 {
+		return nil, errors.New("invalid signature")
+	}
+	sender, err := NewAccountAddress(senderPubkey)
+	if err != nil {
+		return nil, err
+	}
+	return &Extrinsic{
+		Signed:       true,
+		Signer:       sender,
+		Signature:    signature,
+		Nonce:        b.Nonce,
+		Era:          b.Era,
+		Acceleration: b.Acceleration,
+		Call:         b.Call,
+	}, nil
+}
+
+//使用Signer签名并组装交易
+func (b *ExtrinsicBuilder) SignWith(signer Signer) (string, error) {
+	message, err := b.SignData()
+	if err != nil {
+		return "", err
+	}
+	sig, err := signer.Sign(message)
+	if err != nil {
+		return "", fmt.Errorf("sign error,err=%v", err)
+	}
+	return b.Combine(signer.PublicKey(), sig)
+}
+
+//组装不需要签名的交易，例如timestamp
+func (b *ExtrinsicBuilder) Unsigned() (string, error) {
+	e := &Extrinsic{Call: b.Call}
+	return e.Hex()
+}
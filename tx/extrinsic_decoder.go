@@ -0,0 +1,203 @@
+package tx
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	codec "github.com/JFJun/chainX-go/codes"
+	"github.com/JFJun/chainX-go/metadata"
+	"github.com/JFJun/chainX-go/ss58"
+	"github.com/JFJun/chainX-go/util"
+	"strconv"
+)
+
+//交易中的地址，AccountId或者账户索引
+type Address struct {
+	AccountId string `json:"account_id"` //ss58地址，账户索引时为空
+	PublicKey []byte `json:"-"`          //AccountId的公钥，编码时直接使用
+	Index     uint64 `json:"index"`
+	IsIndex   bool   `json:"is_index"`
+}
+
+//公钥对应的地址，AccountId为ChainX格式的ss58地址
+func NewAccountAddress(pub []byte) (Address, error) {
+	if len(pub) != 32 {
+		return Address{}, errors.New("invalid public key")
+	}
+	accountId, err := ss58.Encode(pub, ss58.ChainXPrefix)
+	if err != nil {
+		return Address{}, fmt.Errorf("encode address error,err=%v", err)
+	}
+	return Address{AccountId: accountId, PublicKey: append([]byte{}, pub...)}, nil
+}
+
+//账户索引返回十进制的索引
+func (a Address) String() string {
+	if a.IsIndex {
+		return strconv.FormatUint(a.Index, 10)
+	}
+	return a.AccountId
+}
+
+//AccountId的公钥，PublicKey为空时从AccountId解析
+func (a Address) publicKey() ([]byte, error) {
+	if len(a.PublicKey) == 32 {
+		return a.PublicKey, nil
+	}
+	if a.AccountId == "" {
+		return nil, errors.New("address is empty")
+	}
+	return ss58.DecodeToPub(a.AccountId)
+}
+
+//SCALE编码，公钥为0xff加上32字节，账户索引见codec.EncodeType
+func (a Address) Encode() ([]byte, error) {
+	if a.IsIndex {
+		return codec.EncodeType("Address", a.Index)
+	}
+	pub, err := a.publicKey()
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{0xff}, pub...), nil
+}
+
+//地址相同，比较公钥或者账户索引，不比较ss58的格式
+func (a Address) Equal(other Address) bool {
+	if a.IsIndex || other.IsIndex {
+		return a.IsIndex == other.IsIndex && a.Index == other.Index
+	}
+	pub, err := a.publicKey()
+	if err != nil {
+		return false
+	}
+	otherPub, err := other.publicKey()
+	return err == nil && bytes.Equal(pub, otherPub)
+}
+
+func decodeAddress(d *codec.TypeDecoder) (Address, error) {
+	b, err := d.PeekByte()
+	if err != nil {
+		return Address{}, err
+	}
+	//AccountId保留原始的公钥
+	if b == 0xff {
+		d.NextByte()
+		pub, err := d.NextBytes(32)
+		if err != nil {
+			return Address{}, err
+		}
+		return NewAccountAddress(pub)
+	}
+	v, err := d.Decode("Address")
+	if err != nil {
+		return Address{}, err
+	}
+	if index, ok := v.(uint64); ok {
+		return Address{Index: index, IsIndex: true}, nil
+	}
+	return Address{}, fmt.Errorf("invalid address %v", v)
+}
+
+/*
+DecodedExtrinsic 严格解析出的交易
+CallData为call index加上参数的原始数据，metadata无法解析的call也会保留
+*/
+type DecodedExtrinsic struct {
+	Version      uint8        `json:"version"`
+	Signed       bool         `json:"signed"`
+	Signer       Address      `json:"signer"`
+	Signature    string       `json:"signature"`
+	Nonce        uint64       `json:"nonce"`
+	Era          Era          `json:"era"`
+	Acceleration uint64       `json:"acceleration"`
+	CallIndex    string       `json:"call_index"`
+	CallData     []byte       `json:"call_data"`
+	Call         *DecodedCall `json:"call"` //调用DecodeCall之后才有
+}
+
+func DecodeExtrinsicHex(extrinsic string) (*DecodedExtrinsic, error) {
+	data, err := hex.DecodeString(util.RemoveHex0x(extrinsic))
+	if err != nil {
+		return nil, fmt.Errorf("decode extrinsic hex error,err=%v", err)
+	}
+	return DecodeExtrinsic(data)
+}
+
+/*
+解析交易的外层结构，格式见ExtrinsicBuilder
+数据不够或者长度和数据不一致时返回错误
+*/
+func DecodeExtrinsic(data []byte) (*DecodedExtrinsic, error) {
+	d := codec.NewTypeDecoder(data)
+	length, err := d.DecodeLength()
+	if err != nil {
+		return nil, fmt.Errorf("decode extrinsic length error,err=%v", err)
+	}
+	if length != d.Remaining() {
+		return nil, fmt.Errorf("extrinsic length is %d but data has %d bytes", length, d.Remaining())
+	}
+	version, err := d.NextByte()
+	if err != nil {
+		return nil, err
+	}
+	ext := &DecodedExtrinsic{Version: version & 0x7f, Signed: version&0x80 != 0}
+	if ext.Version != UnsignedChainXBit {
+		return nil, fmt.Errorf("extrinsic version %x is not support", version)
+	}
+	if ext.Signed {
+		if ext.Signer, err = decodeAddress(d); err != nil {
+			return nil, fmt.Errorf("decode signer error,err=%v", err)
+		}
+		sig, err := d.NextBytes(64)
+		if err != nil {
+			return nil, fmt.Errorf("decode signature error,err=%v", err)
+		}
+		ext.Signature = hex.EncodeToString(sig)
+		if ext.Nonce, err = decodeCompactUint64(d); err != nil {
+			return nil, fmt.Errorf("decode nonce error,err=%v", err)
+		}
+		era, n, err := DecodeEra(data[d.Offset():])
+		if err != nil {
+			return nil, fmt.Errorf("decode era error,err=%v", err)
+		}
+		ext.Era = era
+		d.NextBytes(n)
+		if ext.Acceleration, err = decodeCompactUint64(d); err != nil {
+			return nil, fmt.Errorf("decode acceleration error,err=%v", err)
+		}
+	}
+	if d.Remaining() < 2 {
+		return nil, errors.New("extrinsic call is empty")
+	}
+	ext.CallData = data[d.Offset():]
+	ext.CallIndex = hex.EncodeToString(ext.CallData[:2])
+	return ext, nil
+}
+
+func decodeCompactUint64(d *codec.TypeDecoder) (uint64, error) {
+	v, err := d.DecodeCompact()
+	if err != nil {
+		return 0, err
+	}
+	if !v.IsUint64() {
+		return 0, fmt.Errorf("compact %s overflows uint64", v.String())
+	}
+	return v.Uint64(), nil
+}
+
+/*
+按metadata解析CallData，结果保存在Call中
+参数类型不支持时Call中只有Module和Call的名字，CallData仍然保留原始数据
+*/
+func (e *DecodedExtrinsic) DecodeCall(meta *metadata.Metadata) error {
+	call, err := DecodeCall(meta, e.CallData)
+	e.Call = call
+	return err
+}
+
+//CallData的hex，0x开头
+func (e *DecodedExtrinsic) CallHex() string {
+	return "0x" + hex.EncodeToString(e.CallData)
+}
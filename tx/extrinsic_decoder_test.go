@@ -0,0 +1,188 @@
+package tx
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"github.com/JFJun/chainX-go/metadata"
+	"github.com/JFJun/chainX-go/ss58"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+const testSeed = "0x0101010101010101010101010101010101010101010101010101010101010101"
+
+func testTransferCall(t *testing.T) *Call {
+	method, err := NewChainXMethodTransfer(hex.EncodeToString(bytes.Repeat([]byte{0x02}, 32)), "PCX", "memo", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	call, err := NewRawCall(method.Encode(CallIdTransfer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return call
+}
+
+func TestDecodeSignedExtrinsic(t *testing.T) {
+	signer, err := NewEd25519Signer(testSeed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := testTransferCall(t)
+	era, err := NewMortalEra(100, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	birthHash := hex.EncodeToString(bytes.Repeat([]byte{0x03}, 32))
+	b := NewExtrinsicBuilder(call, 7, "")
+	b.SetMortal(era, birthHash)
+	extrinsic, err := b.SignWith(signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ext, err := DecodeExtrinsicHex(extrinsic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	from, _ := ss58.Encode(signer.PublicKey(), ss58.ChainXPrefix)
+	if !ext.Signed || ext.Signer.IsIndex || ext.Signer.AccountId != from {
+		t.Fatalf("signer is %+v,expect %s", ext.Signer, from)
+	}
+	if ext.Nonce != 7 || ext.Era != era || ext.Acceleration != 1 {
+		t.Fatalf("nonce %d era %+v acceleration %d", ext.Nonce, ext.Era, ext.Acceleration)
+	}
+	if ext.CallIndex != CallIdTransfer || !bytes.Equal(ext.CallData, call.Encode()) {
+		t.Fatalf("call index %s call data %x", ext.CallIndex, ext.CallData)
+	}
+
+	data, _ := hex.DecodeString(Remove0X(extrinsic))
+	ce := NewChainXExtrinsic(data)
+	if err := ce.ParseChainXExtrinsic(); err != nil {
+		t.Fatal(err)
+	}
+	if ce.Token != "PCX" || ce.Amount != 100 || ce.Memo != "memo" {
+		t.Fatalf("transfer is %s %d %s", ce.Token, ce.Amount, ce.Memo)
+	}
+	ok, err := ce.VerifySignature(birthHash)
+	if err != nil || !ok {
+		t.Fatalf("verify signature: %v,%v", ok, err)
+	}
+}
+
+func TestDecodeUnsignedExtrinsic(t *testing.T) {
+	call := testTransferCall(t)
+	extrinsic, err := NewExtrinsicBuilder(call, 0, "").Unsigned()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ext, err := DecodeExtrinsicHex(extrinsic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ext.Signed || ext.Signature != "" || !bytes.Equal(ext.CallData, call.Encode()) {
+		t.Fatalf("unsigned extrinsic is %+v", ext)
+	}
+}
+
+func TestParseChainXExtrinsicCallIds(t *testing.T) {
+	load := func(name string) *metadata.Metadata {
+		data, err := ioutil.ReadFile("../metadata/testdata/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := metadata.DecodeHex(strings.TrimSpace(string(data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+	method, err := NewChainXMethodTransfer(hex.EncodeToString(bytes.Repeat([]byte{0x02}, 32)), "PCX", "memo", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		meta   *metadata.Metadata
+		callId string
+		parsed bool
+	}{
+		{nil, CallIdTransfer, true},
+		{load("chainx_v7.hex"), CallIdTransfer, true},
+		//升级后的metadata中XAssets.transfer为0800，0803不是转账
+		{load("substrate_v12.hex"), "0800", true},
+		{load("substrate_v12.hex"), CallIdTransfer, false},
+	}
+	for _, test := range tests {
+		call, err := NewRawCall(method.Encode(test.callId))
+		if err != nil {
+			t.Fatal(err)
+		}
+		extrinsic, err := NewExtrinsicBuilder(call, 0, "").Unsigned()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := hex.DecodeString(Remove0X(extrinsic))
+		ce := NewChainXExtrinsic(data)
+		if test.meta != nil {
+			ce.SetMetadata(test.meta)
+		}
+		if err := ce.ParseChainXExtrinsic(); err != nil {
+			t.Errorf("call id %s: %v", test.callId, err)
+			continue
+		}
+		if parsed := ce.Token == "PCX" && ce.Amount == 100 && ce.Memo == "memo"; parsed != test.parsed {
+			t.Errorf("call id %s parsed is %v,expect %v", test.callId, parsed, test.parsed)
+		}
+	}
+}
+
+//0x81 + 账户索引5 + 签名 + nonce 0 + immortal + acceleration 1 + call
+func indexSignedExtrinsic(address ...byte) []byte {
+	body := append([]byte{0x81}, address...)
+	body = append(body, make([]byte, 64)...)
+	body = append(body, 0x00, 0x00, 0x04, 0x06, 0x00)
+	return withLength(body)
+}
+
+func TestDecodeAccountIndexSigner(t *testing.T) {
+	ext, err := DecodeExtrinsic(indexSignedExtrinsic(0x05))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ext.Signer.IsIndex || ext.Signer.Index != 5 || ext.Signer.String() != "5" {
+		t.Fatalf("signer is %+v", ext.Signer)
+	}
+	ext, err = DecodeExtrinsic(indexSignedExtrinsic(0xfc, 0x00, 0x01))
+	if err != nil || ext.Signer.Index != 0x100 {
+		t.Fatalf("signer is %+v,err=%v", ext.Signer, err)
+	}
+
+	ce := NewChainXExtrinsic(indexSignedExtrinsic(0x05))
+	if err := ce.ParseChainXExtrinsic(); err != nil {
+		t.Fatal(err)
+	}
+	hash := hex.EncodeToString(make([]byte, 32))
+	if _, err := ce.VerifySignature(hash); !errors.Is(err, ErrAccountIndexSigner) {
+		t.Fatalf("verify signature error is %v", err)
+	}
+}
+
+func TestDecodeExtrinsicInvalid(t *testing.T) {
+	valid := indexSignedExtrinsic(0x05)
+	tests := map[string][]byte{
+		"empty":            nil,
+		"truncated":        valid[:len(valid)-1],
+		"extra bytes":      append(append([]byte{}, valid...), 0x00),
+		"version":          withLength([]byte{0x02, 0x06, 0x00}),
+		"non canonical":    indexSignedExtrinsic(0xfc, 0x05, 0x00),
+		"invalid address":  indexSignedExtrinsic(0xf5),
+		"empty call":       withLength([]byte{0x01}),
+		"short call index": withLength([]byte{0x01, 0x06}),
+	}
+	for name, data := range tests {
+		if _, err := DecodeExtrinsic(data); err == nil {
+			t.Errorf("%s: expect error", name)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package tx
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	codec "github.com/JFJun/chainX-go/codes"
+	"github.com/JFJun/chainX-go/util"
+	"golang.org/x/crypto/blake2b"
+)
+
+/*
+Extrinsic ChainX交易，可以编码为SCALE数据，也可以从区块或者交易池中的数据解析
+Signed为false时只有Call有值
+Call的Module和Name不参与编码，解析出的Call只有CallIndex和Args，比较交易使用Equal
+*/
+type Extrinsic struct {
+	Signed       bool
+	Signer       Address
+	Signature    []byte
+	Nonce        uint64
+	Era          Era
+	Acceleration uint64
+	Call         *Call
+}
+
+//编码后的交易，包括开头的长度
+func (e *Extrinsic) MarshalSCALE() ([]byte, error) {
+	if e.Call == nil {
+		return nil, errors.New("extrinsic call is nil")
+	}
+	if !e.Signed {
+		return withLength(append([]byte{UnsignedChainXBit}, e.Call.Encode()...)), nil
+	}
+	address, err := e.Signer.Encode()
+	if err != nil {
+		return nil, fmt.Errorf("encode signer error,err=%v", err)
+	}
+	//chainX 1.0使用AnySignature，签名前不需要加 ed25519: 0x00 sr25519: 0x01 的类型字节
+	if len(e.Signature) != 64 {
+		return nil, errors.New("invalid signature")
+	}
+	signed := append([]byte{SigningChainXBit}, address...)
+	signed = append(signed, e.Signature...)
+	signed = append(signed, codec.CompactBytes(e.Nonce)...)
+	signed = append(signed, e.Era.Encode()...)
+	signed = append(signed, codec.CompactBytes(e.Acceleration)...)
+	signed = append(signed, e.Call.Encode()...)
+	return withLength(signed), nil
+}
+
+//解析包括长度的交易数据，数据不完整或者有多余的字节时返回错误
+func (e *Extrinsic) UnmarshalSCALE(data []byte) error {
+	ext, err := DecodeExtrinsic(data)
+	if err != nil {
+		return err
+	}
+	call, err := NewRawCall(append([]byte{}, ext.CallData...))
+	if err != nil {
+		return err
+	}
+	*e = Extrinsic{Signed: ext.Signed, Call: call}
+	if ext.Signed {
+		e.Signer = ext.Signer
+		e.Signature, _ = hex.DecodeString(ext.Signature)
+		e.Nonce = ext.Nonce
+		e.Era = ext.Era
+		e.Acceleration = ext.Acceleration
+	}
+	return nil
+}
+
+//编码后相同的交易，不比较Call的Module和Name以及地址的ss58格式
+func (e *Extrinsic) Equal(other *Extrinsic) bool {
+	if e.Call == nil || other.Call == nil || !bytes.Equal(e.Call.Encode(), other.Call.Encode()) {
+		return false
+	}
+	if e.Signed != other.Signed {
+		return false
+	}
+	if !e.Signed {
+		return true
+	}
+	return e.Signer.Equal(other.Signer) && bytes.Equal(e.Signature, other.Signature) && e.Nonce == other.Nonce &&
+		e.Era == other.Era && e.Acceleration == other.Acceleration
+}
+
+//0x开头的hex，可以直接提交
+func (e *Extrinsic) Hex() (string, error) {
+	data, err := e.MarshalSCALE()
+	if err != nil {
+		return "", err
+	}
+	return "0x" + hex.EncodeToString(data), nil
+}
+
+//交易hash，编码后数据的blake2-256
+func (e *Extrinsic) Hash() (string, error) {
+	data, err := e.MarshalSCALE()
+	if err != nil {
+		return "", err
+	}
+	h := blake2b.Sum256(data)
+	return "0x" + hex.EncodeToString(h[:]), nil
+}
+
+//修改交易后重新签名使用的数据，blockHash为immortal时的genesis hash或者mortal时birth区块的hash
+func (e *Extrinsic) SigningPayload(blockHash string) (*SigningPayload, error) {
+	b := NewExtrinsicBuilder(e.Call, e.Nonce, blockHash)
+	b.Era = e.Era
+	b.Acceleration = e.Acceleration
+	return b.SigningPayload()
+}
+
+func ExtrinsicFromHex(extrinsic string) (*Extrinsic, error) {
+	data, err := hex.DecodeString(util.RemoveHex0x(extrinsic))
+	if err != nil {
+		return nil, fmt.Errorf("decode extrinsic hex error,err=%v", err)
+	}
+	e := new(Extrinsic)
+	if err := e.UnmarshalSCALE(data); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func withLength(data []byte) []byte {
+	return append(codec.CompactBytes(uint64(len(data))), data...)
+}
@@ -0,0 +1,109 @@
+package tx
+
+import (
+	"bytes"
+	"encoding/hex"
+	"github.com/JFJun/chainX-go/ss58"
+	"testing"
+)
+
+func testRoundTrip(t *testing.T, e *Extrinsic) *Extrinsic {
+	data, err := e.MarshalSCALE()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := new(Extrinsic)
+	if err := decoded.UnmarshalSCALE(data); err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Equal(e) || !e.Equal(decoded) {
+		t.Fatalf("decoded extrinsic %+v is not equal %+v", decoded, e)
+	}
+	encoded, err := decoded.MarshalSCALE()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(encoded, data) {
+		t.Fatalf("encoded %x,expect %x", encoded, data)
+	}
+	return decoded
+}
+
+func TestSignedExtrinsicRoundTrip(t *testing.T) {
+	signer, err := NewEd25519Signer(testSeed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := testTransferCall(t)
+	call.Module, call.Name = "XAssets", "transfer"
+	era, err := NewMortalEra(10001, 8192)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := NewExtrinsicBuilder(call, 300, "")
+	b.SetMortal(era, "0x"+hex.EncodeToString(bytes.Repeat([]byte{0x03}, 32)))
+	b.Acceleration = 3
+	message, err := b.SignData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := signer.Sign(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := b.Build(signer.PublicKey(), sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(e.Signer.PublicKey, signer.PublicKey()) {
+		t.Fatalf("signer public key is %x", e.Signer.PublicKey)
+	}
+	decoded := testRoundTrip(t, e)
+	if !bytes.Equal(decoded.Signer.PublicKey, signer.PublicKey()) {
+		t.Fatalf("decoded signer public key is %x", decoded.Signer.PublicKey)
+	}
+	if decoded.Call.Module != "" || decoded.Call.Name != "" {
+		t.Fatalf("decoded call has name %s.%s", decoded.Call.Module, decoded.Call.Name)
+	}
+}
+
+func TestSignerAddressFormat(t *testing.T) {
+	pub := bytes.Repeat([]byte{0x04}, 32)
+	//其他格式的ss58地址也按公钥编码
+	accountId, err := ss58.Encode(pub, []byte{0x2a})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := &Extrinsic{
+		Signed:       true,
+		Signer:       Address{AccountId: accountId},
+		Signature:    bytes.Repeat([]byte{0x05}, 64),
+		Era:          ImmortalEra(),
+		Acceleration: 1,
+		Call:         testTransferCall(t),
+	}
+	decoded := testRoundTrip(t, e)
+	if !bytes.Equal(decoded.Signer.PublicKey, pub) {
+		t.Fatalf("decoded signer public key is %x", decoded.Signer.PublicKey)
+	}
+}
+
+func TestIndexSignerRoundTrip(t *testing.T) {
+	for _, index := range []uint64{5, 0xef, 0x100, 0x10000, 0x100000000} {
+		testRoundTrip(t, &Extrinsic{
+			Signed:       true,
+			Signer:       Address{Index: index, IsIndex: true},
+			Signature:    bytes.Repeat([]byte{0x05}, 64),
+			Nonce:        1,
+			Acceleration: 1,
+			Call:         testTransferCall(t),
+		})
+	}
+}
+
+func TestUnsignedExtrinsicRoundTrip(t *testing.T) {
+	decoded := testRoundTrip(t, &Extrinsic{Call: testTransferCall(t)})
+	if decoded.Signed || decoded.Signature != nil {
+		t.Fatalf("decoded extrinsic is signed")
+	}
+}
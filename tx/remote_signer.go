@@ -0,0 +1,116 @@
+package tx
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const remoteSignTimeout = 30 * time.Second
+
+/*
+RemoteSigner 调用远程签名服务签名，私钥保存在签名服务(例如HSM)中
+签名服务提供两个json接口，请求和返回都是POST json：
+	{url}/Signer/PublicKey  请求 {"key_id":""}                 返回 {"public_key":"0x..."}
+	{url}/Signer/Sign       请求 {"key_id":"","payload":"0x..."} 返回 {"signature":"0x..."}
+出错时返回 {"error":"..."}
+*/
+type RemoteSigner struct {
+	url        string
+	keyId      string
+	publicKey  []byte
+	header     http.Header
+	httpClient *http.Client
+}
+
+type remoteSignRequest struct {
+	KeyId   string `json:"key_id"`
+	Payload string `json:"payload,omitempty"`
+}
+
+type remoteSignResponse struct {
+	PublicKey string `json:"public_key"`
+	Signature string `json:"signature"`
+	Error     string `json:"error"`
+}
+
+//创建远程签名，从签名服务获取公钥，header可以为nil，用于设置认证信息
+func NewRemoteSigner(url, keyId string, header http.Header) (*RemoteSigner, error) {
+	s := &RemoteSigner{
+		url:        strings.TrimRight(url, "/"),
+		keyId:      keyId,
+		header:     header,
+		httpClient: &http.Client{Timeout: remoteSignTimeout},
+	}
+	resp, err := s.call("PublicKey", remoteSignRequest{KeyId: keyId})
+	if err != nil {
+		return nil, err
+	}
+	pub, err := hex.DecodeString(Remove0X(resp.PublicKey))
+	if err != nil || len(pub) != 32 {
+		return nil, errors.New("remote signer return invalid public key")
+	}
+	s.publicKey = pub
+	return s, nil
+}
+
+func (s *RemoteSigner) PublicKey() []byte {
+	return s.publicKey
+}
+
+//签名后在本地验证签名，防止签名服务返回错误的签名
+func (s *RemoteSigner) Sign(message []byte) ([]byte, error) {
+	resp, err := s.call("Sign", remoteSignRequest{KeyId: s.keyId, Payload: "0x" + hex.EncodeToString(message)})
+	if err != nil {
+		return nil, err
+	}
+	sig, err := hex.DecodeString(Remove0X(resp.Signature))
+	if err != nil || len(sig) != 64 {
+		return nil, errors.New("remote signer return invalid signature")
+	}
+	if !VerifySignature(message, sig, s.publicKey) {
+		return nil, errors.New("remote signer return signature verify failed")
+	}
+	return sig, nil
+}
+
+func (s *RemoteSigner) call(method string, req remoteSignRequest) (*remoteSignResponse, error) {
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequest(http.MethodPost, s.url+"/Signer/"+method, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range s.header {
+		httpReq.Header[k] = v
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	res, err := s.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("remote signer %s error,err=%v", method, err)
+	}
+	defer res.Body.Close()
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("remote signer %s error,err=%v", method, err)
+	}
+	var resp remoteSignResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("remote signer %s error,status=%d,data=%s", method, res.StatusCode, string(data))
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("remote signer %s error,err=%s", method, resp.Error)
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("remote signer %s error,status=%d", method, res.StatusCode)
+	}
+	return &resp, nil
+}
@@ -0,0 +1,129 @@
+package tx
+
+import (
+	"encoding/hex"
+	"errors"
+
+	"github.com/ChainSafe/go-schnorrkel"
+	"golang.org/x/crypto/ed25519"
+)
+
+var sr25519SigningContext = []byte("substrate")
+
+//Signer 对交易的签名数据进行签名
+type Signer interface {
+	PublicKey() []byte
+	Sign(message []byte) ([]byte, error)
+}
+
+//使用ed25519私钥签名
+type Ed25519Signer struct {
+	privKey ed25519.PrivateKey
+}
+
+//seed为32字节的私钥种子，hex格式
+func NewEd25519Signer(seed string) (*Ed25519Signer, error) {
+	priv, err := hex.DecodeString(Remove0X(seed))
+	if err != nil {
+		return nil, err
+	}
+	if len(priv) != ed25519.SeedSize {
+		return nil, errors.New("invalid ed25519 seed length")
+	}
+	return &Ed25519Signer{privKey: ed25519.NewKeyFromSeed(priv)}, nil
+}
+
+func (s *Ed25519Signer) PublicKey() []byte {
+	return []byte(s.privKey.Public().(ed25519.PublicKey))
+}
+
+func (s *Ed25519Signer) Sign(message []byte) ([]byte, error) {
+	sig := ed25519.Sign(s.privKey, message)
+	if len(sig) != 64 {
+		return nil, errors.New("sign fail,sig length is not equal 64")
+	}
+	return sig, nil
+}
+
+//使用sr25519(schnorrkel)私钥签名，和substrate的签名方式一致
+type Sr25519Signer struct {
+	secretKey *schnorrkel.SecretKey
+	publicKey *schnorrkel.PublicKey
+}
+
+/*
+seed为hex格式的私钥
+32字节时作为mini secret key，和polkadot.js一样使用ed25519方式扩展
+64字节时为扩展后的secret key(32字节key + 32字节nonce)
+*/
+func NewSr25519Signer(seed string) (*Sr25519Signer, error) {
+	priv, err := hex.DecodeString(Remove0X(seed))
+	if err != nil {
+		return nil, err
+	}
+	var secretKey *schnorrkel.SecretKey
+	switch len(priv) {
+	case 32:
+		var raw [32]byte
+		copy(raw[:], priv)
+		mini, err := schnorrkel.NewMiniSecretKeyFromRaw(raw)
+		if err != nil {
+			return nil, err
+		}
+		secretKey = mini.ExpandEd25519()
+	case 64:
+		var key, nonce [32]byte
+		copy(key[:], priv[:32])
+		copy(nonce[:], priv[32:])
+		secretKey = schnorrkel.NewSecretKey(key, nonce)
+	default:
+		return nil, errors.New("invalid sr25519 seed length")
+	}
+	publicKey, err := secretKey.Public()
+	if err != nil {
+		return nil, err
+	}
+	return &Sr25519Signer{secretKey: secretKey, publicKey: publicKey}, nil
+}
+
+func (s *Sr25519Signer) PublicKey() []byte {
+	pub := s.publicKey.Encode()
+	return pub[:]
+}
+
+func (s *Sr25519Signer) Sign(message []byte) ([]byte, error) {
+	sig, err := s.secretKey.Sign(schnorrkel.NewSigningContext(sr25519SigningContext, message))
+	if err != nil {
+		return nil, err
+	}
+	data := sig.Encode()
+	return data[:], nil
+}
+
+/*
+验证签名，和chainX 1.0的AnySignature一样，先按sr25519验证，失败后再按ed25519验证
+*/
+func VerifySignature(payload, signature, publicKey []byte) bool {
+	if len(signature) != 64 || len(publicKey) != 32 {
+		return false
+	}
+	return verifySr25519(payload, signature, publicKey) || ed25519.Verify(ed25519.PublicKey(publicKey), payload, signature)
+}
+
+func verifySr25519(payload, signature, publicKey []byte) bool {
+	var (
+		pubBytes [32]byte
+		sigBytes [64]byte
+	)
+	copy(pubBytes[:], publicKey)
+	copy(sigBytes[:], signature)
+	pub := new(schnorrkel.PublicKey)
+	if err := pub.Decode(pubBytes); err != nil {
+		return false
+	}
+	sig := new(schnorrkel.Signature)
+	if err := sig.Decode(sigBytes); err != nil {
+		return false
+	}
+	return pub.Verify(sig, schnorrkel.NewSigningContext(sr25519SigningContext, payload))
+}
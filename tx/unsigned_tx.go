@@ -0,0 +1,172 @@
+package tx
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/JFJun/chainX-go/ss58"
+	"github.com/fxamacker/cbor/v2"
+)
+
+/*
+UnsignedTx 离线签名使用的未签名交易
+在线的机器生成UnsignedTx，序列化为JSON或者CBOR后传到离线的机器
+离线的机器用SigningPayload得到签名的数据，签名后用Attach得到可以提交的交易
+*/
+type UnsignedTx struct {
+	Sender       string `json:"sender,omitempty"` //ss58地址，不为空时Attach检查公钥是否一致
+	Call         string `json:"call"`             //call index加上参数，0x开头
+	Nonce        uint64 `json:"nonce"`
+	Era          Era    `json:"era"`
+	Acceleration uint64 `json:"acceleration"`
+	BlockHash    string `json:"block_hash"` //签名使用的区块hash，immortal时为空或者和GenesisHash相同，mortal时为birth区块的hash
+	GenesisHash  string `json:"genesis_hash"`
+}
+
+//Era默认为immortal，Acceleration默认为1
+func NewUnsignedTx(call *Call, nonce uint64, genesisHash string) *UnsignedTx {
+	return &UnsignedTx{
+		Call:         call.Hex(),
+		Nonce:        nonce,
+		Acceleration: 1,
+		BlockHash:    genesisHash,
+		GenesisHash:  genesisHash,
+	}
+}
+
+//转账交易的UnsignedTx，GenesisHash和BlockHash需要先设置好
+func (t *ChainXTransaction) UnsignedTx() (*UnsignedTx, error) {
+	b, err := t.builder()
+	if err != nil {
+		return nil, err
+	}
+	sender, err := ss58.EncodeByPubHex(Remove0X(t.SenderPubkey), ss58.ChainXPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("encode sender address error,err=%v", err)
+	}
+	return &UnsignedTx{
+		Sender:       sender,
+		Call:         b.Call.Hex(),
+		Nonce:        b.Nonce,
+		Era:          b.Era,
+		Acceleration: b.Acceleration,
+		BlockHash:    add0X(b.BlockHash),
+		GenesisHash:  add0X(t.GenesisHash),
+	}, nil
+}
+
+func (u *UnsignedTx) builder() (*ExtrinsicBuilder, error) {
+	callData, err := hex.DecodeString(Remove0X(u.Call))
+	if err != nil {
+		return nil, fmt.Errorf("decode call hex error,err=%v", err)
+	}
+	call, err := NewRawCall(callData)
+	if err != nil {
+		return nil, err
+	}
+	//Era必须是可以编码的，否则签名的era和交易中的era不一致
+	era, _, err := DecodeEra(u.Era.Encode())
+	if err != nil || era != u.Era {
+		return nil, fmt.Errorf("invalid era %+v", u.Era)
+	}
+	blockHash := u.BlockHash
+	if u.Era.IsMortal && blockHash == "" {
+		return nil, errors.New("mortal transaction need birth block hash")
+	}
+	if !u.Era.IsMortal {
+		if blockHash == "" {
+			blockHash = u.GenesisHash
+		}
+		if u.GenesisHash != "" && Remove0X(blockHash) != Remove0X(u.GenesisHash) {
+			return nil, errors.New("immortal transaction block hash must be genesis hash")
+		}
+	}
+	b := NewExtrinsicBuilder(call, u.Nonce, blockHash)
+	b.Era = u.Era
+	b.Acceleration = u.Acceleration
+	return b, nil
+}
+
+//离线计算签名的数据，不需要连接节点
+func (u *UnsignedTx) SigningPayload() (*SigningPayload, error) {
+	b, err := u.builder()
+	if err != nil {
+		return nil, err
+	}
+	return b.SigningPayload()
+}
+
+/*
+加上签名和公钥得到可以提交的交易，返回0x开头的hex
+签名必须是对SigningPayload().Signed的签名，验证失败时返回错误
+*/
+func (u *UnsignedTx) Attach(signature, pubkey []byte) (string, error) {
+	if u.Sender != "" {
+		sender, err := ss58.DecodeToPub(u.Sender)
+		if err != nil {
+			return "", fmt.Errorf("decode sender address error,err=%v", err)
+		}
+		if !bytes.Equal(sender, pubkey) {
+			return "", errors.New("public key is not equal sender public key")
+		}
+	}
+	b, err := u.builder()
+	if err != nil {
+		return "", err
+	}
+	payload, err := b.SigningPayload()
+	if err != nil {
+		return "", err
+	}
+	if !VerifySignature(payload.Signed, signature, pubkey) {
+		return "", errors.New("signature verify failed")
+	}
+	return b.Combine(pubkey, signature)
+}
+
+//使用Signer签名，例如离线机器上的私钥
+func (u *UnsignedTx) Sign(signer Signer) (string, error) {
+	payload, err := u.SigningPayload()
+	if err != nil {
+		return "", err
+	}
+	sig, err := signer.Sign(payload.Signed)
+	if err != nil {
+		return "", fmt.Errorf("sign error,err=%v", err)
+	}
+	return u.Attach(sig, signer.PublicKey())
+}
+
+func (u *UnsignedTx) ToJSON() ([]byte, error) {
+	return json.Marshal(u)
+}
+
+func UnsignedTxFromJSON(data []byte) (*UnsignedTx, error) {
+	u := new(UnsignedTx)
+	if err := json.Unmarshal(data, u); err != nil {
+		return nil, fmt.Errorf("parse unsigned tx json error,err=%v", err)
+	}
+	return u, nil
+}
+
+//CBOR使用和JSON相同的字段名
+func (u *UnsignedTx) ToCBOR() ([]byte, error) {
+	return cbor.Marshal(u)
+}
+
+func UnsignedTxFromCBOR(data []byte) (*UnsignedTx, error) {
+	u := new(UnsignedTx)
+	if err := cbor.Unmarshal(data, u); err != nil {
+		return nil, fmt.Errorf("parse unsigned tx cbor error,err=%v", err)
+	}
+	return u, nil
+}
+
+func add0X(hexData string) string {
+	if hexData == "" {
+		return ""
+	}
+	return "0x" + Remove0X(hexData)
+}
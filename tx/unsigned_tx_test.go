@@ -0,0 +1,38 @@
+package tx
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestUnsignedTxInvalidEra(t *testing.T) {
+	genesisHash := "0x" + hex.EncodeToString(bytes.Repeat([]byte{0x01}, 32))
+	birthHash := "0x" + hex.EncodeToString(bytes.Repeat([]byte{0x02}, 32))
+	eras := []Era{
+		{IsMortal: true, Period: 100, Phase: 10},
+		{IsMortal: true, Period: 64, Phase: 64},
+		//period大于4096时phase必须按精度取整
+		{IsMortal: true, Period: 8192, Phase: 1809},
+		{IsMortal: false, Period: 64},
+	}
+	for _, era := range eras {
+		u := NewUnsignedTx(testTransferCall(t), 0, genesisHash)
+		u.Era = era
+		u.BlockHash = birthHash
+		if _, err := u.SigningPayload(); err == nil {
+			t.Errorf("era %+v: expect error", era)
+		}
+	}
+
+	u := NewUnsignedTx(testTransferCall(t), 0, genesisHash)
+	u.Era, _ = NewMortalEra(100, 64)
+	u.BlockHash = ""
+	if _, err := u.SigningPayload(); err == nil {
+		t.Error("mortal transaction without block hash: expect error")
+	}
+	u.BlockHash = birthHash
+	if _, err := u.SigningPayload(); err != nil {
+		t.Errorf("mortal transaction: %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+//substrate交易池返回的错误码
+const (
+	ErrCodeInvalidTransaction = 1010 //交易无效，nonce过期时也是这个错误码
+	ErrCodeUnknownValidity    = 1011 //无法确定交易是否有效
+	ErrCodeTemporarilyBanned  = 1012 //交易被暂时禁止
+	ErrCodeAlreadyImported    = 1013 //交易已经在交易池中
+	ErrCodePriorityTooLow     = 1014 //相同nonce的交易已经存在，并且优先级更高
+	ErrCodeCycleDetected      = 1015 //交易依赖出现循环
+	ErrCodePoolFull           = 1016 //交易池已满，交易被立即丢弃
+)
+
+//json-rpc 2.0的错误码，节点不支持调用的方法
+const ErrCodeMethodNotFound = -32601
+
+//RPCError 节点返回的json-rpc错误
+type RPCError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	if e.Data != nil {
+		return fmt.Sprintf("Rpc get error,Code=【%d】,Message=【%s】,Data=【%v】", e.Code, e.Message, e.Data)
+	}
+	return fmt.Sprintf("Rpc get error,Code=【%d】,Message=【%s】", e.Code, e.Message)
+}
+
+//从err中获取节点返回的错误，支持被包装过的err
+func AsRPCError(err error) (*RPCError, bool) {
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) {
+		return rpcErr, true
+	}
+	return nil, false
+}
+
+func IsInvalidTransaction(err error) bool {
+	return hasErrCode(err, ErrCodeInvalidTransaction)
+}
+
+//nonce小于账户当前的nonce
+func IsStaleNonce(err error) bool {
+	rpcErr, ok := AsRPCError(err)
+	if !ok || rpcErr.Code != ErrCodeInvalidTransaction {
+		return false
+	}
+	detail := strings.ToLower(rpcErr.Message + " " + fmt.Sprint(rpcErr.Data))
+	return strings.Contains(detail, "stale") || strings.Contains(detail, "outdated")
+}
+
+func IsAlreadyImported(err error) bool {
+	return hasErrCode(err, ErrCodeAlreadyImported)
+}
+
+func IsPriorityTooLow(err error) bool {
+	return hasErrCode(err, ErrCodePriorityTooLow)
+}
+
+func IsPoolFull(err error) bool {
+	return hasErrCode(err, ErrCodePoolFull)
+}
+
+//节点不支持调用的方法，例如没有开启chainx的rpc
+func IsMethodNotFound(err error) bool {
+	return hasErrCode(err, ErrCodeMethodNotFound)
+}
+
+func hasErrCode(err error, code int) bool {
+	rpcErr, ok := AsRPCError(err)
+	return ok && rpcErr.Code == code
+}
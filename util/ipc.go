@@ -0,0 +1,49 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"time"
+)
+
+const ipcDialTimeout = 10 * time.Second
+
+//IpcClient 通过unix socket连接本地节点，消息为连续的json
+type IpcClient struct {
+	*streamClient
+}
+
+type ipcConn struct {
+	conn    net.Conn
+	decoder *json.Decoder
+}
+
+//初始化一个ipc客户端，path为unix socket文件路径
+func NewIpcClient(path string) *IpcClient {
+	dial := func() (streamConn, error) {
+		conn, err := net.DialTimeout("unix", path, ipcDialTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("dial ipc error,path=%s,err=%v", path, err)
+		}
+		return &ipcConn{conn: conn, decoder: json.NewDecoder(conn)}, nil
+	}
+	return &IpcClient{streamClient: newStreamClient(dial)}
+}
+
+func (ic *ipcConn) ReadMessage() ([]byte, error) {
+	var msg json.RawMessage
+	if err := ic.decoder.Decode(&msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func (ic *ipcConn) WriteMessage(data []byte) error {
+	_, err := ic.conn.Write(append(data, '\n'))
+	return err
+}
+
+func (ic *ipcConn) Close() error {
+	return ic.conn.Close()
+}
@@ -0,0 +1,294 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+/*
+MockTransport 内存中的Transport，用于单元测试
+按 method 和 params 返回事先设置或者录制好的结果，可以保存为json后重放
+*/
+type MockTransport struct {
+	mu      sync.Mutex
+	records []*Record
+	index   map[string]*Record
+	nextSub int
+}
+
+//一次调用的记录，Result和Call返回的数据一致
+type Record struct {
+	Method        string            `json:"method"`
+	Params        json.RawMessage   `json:"params"`
+	Result        string            `json:"result,omitempty"`
+	Error         string            `json:"error,omitempty"`
+	RPCError      *RPCError         `json:"rpc_error,omitempty"`
+	Notifications []json.RawMessage `json:"notifications,omitempty"`
+}
+
+func NewMockTransport() *MockTransport {
+	return &MockTransport{
+		index: make(map[string]*Record),
+	}
+}
+
+//从Save保存的数据中加载记录
+func LoadMockTransport(r io.Reader) (*MockTransport, error) {
+	var records []*Record
+	if err := json.NewDecoder(r).Decode(&records); err != nil {
+		return nil, fmt.Errorf("load mock records error,err=%v", err)
+	}
+	m := NewMockTransport()
+	for _, record := range records {
+		//保存时params被格式化过，重新压缩后才能匹配
+		var params bytes.Buffer
+		if err := json.Compact(&params, record.Params); err != nil {
+			return nil, fmt.Errorf("load mock records error,err=%v", err)
+		}
+		record.Params = params.Bytes()
+		m.add(record)
+	}
+	return m, nil
+}
+
+func (m *MockTransport) Save(w io.Writer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, err := json.MarshalIndent(m.records, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+//设置method和params对应的返回结果，string和[]byte原样返回，其他类型json编码后返回
+func (m *MockTransport) Add(method string, params []interface{}, result interface{}) error {
+	record, err := newRecord(method, params)
+	if err != nil {
+		return err
+	}
+	switch v := result.(type) {
+	case string:
+		record.Result = v
+	case []byte:
+		record.Result = string(v)
+	default:
+		data, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		record.Result = string(data)
+	}
+	m.add(record)
+	return nil
+}
+
+func (m *MockTransport) AddError(method string, params []interface{}, err error) error {
+	record, err1 := newRecord(method, params)
+	if err1 != nil {
+		return err1
+	}
+	record.Error = err.Error()
+	if rpcErr, ok := AsRPCError(err); ok {
+		record.RPCError = rpcErr
+	}
+	m.add(record)
+	return nil
+}
+
+//设置订阅推送的数据，订阅成功后按顺序推送
+func (m *MockTransport) AddSubscription(method string, params []interface{}, notifications ...interface{}) error {
+	record, err := newRecord(method, params)
+	if err != nil {
+		return err
+	}
+	for _, n := range notifications {
+		data, err := json.Marshal(n)
+		if err != nil {
+			return err
+		}
+		record.Notifications = append(record.Notifications, data)
+	}
+	m.add(record)
+	return nil
+}
+
+func (m *MockTransport) Call(method string, params []interface{}) ([]byte, error) {
+	return m.CallContext(context.Background(), method, params)
+}
+
+func (m *MockTransport) CallContext(ctx context.Context, method string, params []interface{}) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	record, err := m.find(method, params)
+	if err != nil {
+		return nil, err
+	}
+	if err := record.err(); err != nil {
+		return nil, err
+	}
+	return []byte(record.Result), nil
+}
+
+func (m *MockTransport) BatchCall(requests []Request) ([]BatchResult, error) {
+	return m.BatchCallContext(context.Background(), requests)
+}
+
+func (m *MockTransport) BatchCallContext(ctx context.Context, requests []Request) ([]BatchResult, error) {
+	return batchCallOneByOne(ctx, m, requests)
+}
+
+func (m *MockTransport) Subscribe(method, unsubscribeMethod string, params []interface{}) (*Subscription, error) {
+	record, err := m.find(method, params)
+	if err != nil {
+		return nil, err
+	}
+	if err := record.err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	m.nextSub++
+	sub := newSubscription(m, "")
+	sub.id = fmt.Sprintf("%d", m.nextSub)
+	m.mu.Unlock()
+	//重放时没有读取goroutine，等待推送被读取，不会因为缓冲区满了结束订阅
+	go func() {
+		for _, n := range record.Notifications {
+			select {
+			case sub.notifyCh <- n:
+			case <-sub.quit:
+				return
+			}
+		}
+	}()
+	return sub, nil
+}
+
+func (m *MockTransport) Close() error {
+	return nil
+}
+
+func (m *MockTransport) removeSubscription(sub *Subscription) string {
+	return sub.id
+}
+
+func (m *MockTransport) add(record *Record) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := record.Method + string(record.Params)
+	if old, ok := m.index[key]; ok {
+		*old = *record
+		return
+	}
+	m.index[key] = record
+	m.records = append(m.records, record)
+}
+
+func (m *MockTransport) find(method string, params []interface{}) (*Record, error) {
+	p, err := marshalParams(params)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	record, ok := m.index[method+string(p)]
+	if !ok {
+		return nil, fmt.Errorf("mock transport has no record,method=%s,params=%s", method, string(p))
+	}
+	return record, nil
+}
+
+func (r *Record) err() error {
+	if r.RPCError != nil {
+		return r.RPCError
+	}
+	if r.Error == ErrNullResult.Error() {
+		return ErrNullResult
+	}
+	if r.Error != "" {
+		return errors.New(r.Error)
+	}
+	return nil
+}
+
+func newRecord(method string, params []interface{}) (*Record, error) {
+	p, err := marshalParams(params)
+	if err != nil {
+		return nil, err
+	}
+	return &Record{Method: method, Params: p}, nil
+}
+
+func marshalParams(params []interface{}) (json.RawMessage, error) {
+	if params == nil {
+		params = []interface{}{}
+	}
+	return json.Marshal(params)
+}
+
+/*
+RecordingTransport 包装一个真实的Transport，记录所有的调用结果
+保存之后可以用LoadMockTransport重放，订阅不会被记录
+*/
+type RecordingTransport struct {
+	Transport
+	records *MockTransport
+}
+
+func NewRecordingTransport(t Transport) *RecordingTransport {
+	return &RecordingTransport{
+		Transport: t,
+		records:   NewMockTransport(),
+	}
+}
+
+func (r *RecordingTransport) Call(method string, params []interface{}) ([]byte, error) {
+	return r.CallContext(context.Background(), method, params)
+}
+
+func (r *RecordingTransport) CallContext(ctx context.Context, method string, params []interface{}) ([]byte, error) {
+	result, err := r.Transport.CallContext(ctx, method, params)
+	r.record(method, params, result, err)
+	return result, err
+}
+
+func (r *RecordingTransport) BatchCall(requests []Request) ([]BatchResult, error) {
+	return r.BatchCallContext(context.Background(), requests)
+}
+
+func (r *RecordingTransport) BatchCallContext(ctx context.Context, requests []Request) ([]BatchResult, error) {
+	results, err := r.Transport.BatchCallContext(ctx, requests)
+	if err != nil {
+		return nil, err
+	}
+	for i, req := range requests {
+		if i < len(results) {
+			r.record(req.Method, req.Params, results[i].Result, results[i].Error)
+		}
+	}
+	return results, nil
+}
+
+//录制的结果，可以直接作为MockTransport使用
+func (r *RecordingTransport) Records() *MockTransport {
+	return r.records
+}
+
+func (r *RecordingTransport) Save(w io.Writer) error {
+	return r.records.Save(w)
+}
+
+func (r *RecordingTransport) record(method string, params []interface{}, result []byte, err error) {
+	if err != nil {
+		r.records.AddError(method, params, err)
+		return
+	}
+	r.records.Add(method, params, result)
+}
@@ -0,0 +1,76 @@
+package util
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRecordingTransportReplay(t *testing.T) {
+	server := testRpcServer(t)
+	defer server.Close()
+	recorder := NewRecordingTransport(New(server.URL, "", ""))
+	if _, err := recorder.Call("test_string", []interface{}{"0x00"}); err != nil {
+		t.Fatal(err)
+	}
+	recorder.Call("test_null", nil)
+	recorder.Call("test_error", nil)
+	if _, err := recorder.BatchCall([]Request{{Method: "test_object"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := recorder.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	mock, err := LoadMockTransport(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := mock.Call("test_string", []interface{}{"0x00"})
+	if err != nil || string(data) != "0x01" {
+		t.Fatalf("test_string result is %s,err=%v", data, err)
+	}
+	//params不同时没有记录
+	if _, err := mock.Call("test_string", []interface{}{"0x01"}); err == nil {
+		t.Fatal("test_string with other params: expect error")
+	}
+	//重放时保留null结果和节点返回的错误
+	if _, err := mock.Call("test_null", nil); !errors.Is(err, ErrNullResult) {
+		t.Fatalf("test_null error is %v", err)
+	}
+	if _, err := mock.Call("test_error", nil); !IsStaleNonce(err) {
+		t.Fatalf("test_error error is %v", err)
+	}
+	results, err := mock.BatchCall([]Request{{Method: "test_object"}, {Method: "test_null"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(results[0].Result) != `{"specVersion":1}` || !errors.Is(results[1].Error, ErrNullResult) {
+		t.Fatalf("batch results are %+v", results)
+	}
+}
+
+func TestMockTransportSubscribe(t *testing.T) {
+	mock := NewMockTransport()
+	if err := mock.AddSubscription("test_subscribe", nil, 1, 2); err != nil {
+		t.Fatal(err)
+	}
+	sub, err := mock.Subscribe("test_subscribe", "test_unsubscribe", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expect := range []string{"1", "2"} {
+		waitNotification(t, sub, expect)
+	}
+	sub.Unsubscribe()
+	select {
+	case <-sub.Done():
+	case <-time.After(time.Second):
+		t.Fatal("subscription is not done")
+	}
+	if _, err := mock.Subscribe("test_other", "test_unsubscribe", nil); err == nil {
+		t.Fatal("subscribe without record: expect error")
+	}
+}
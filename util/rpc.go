@@ -2,18 +2,65 @@ package util
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"math/rand"
+	"net"
 	"net/http"
+	"time"
 )
 
+const (
+	defaultCallTimeout = 60 * time.Second
+	defaultMinBackoff  = 200 * time.Millisecond
+	defaultMaxBackoff  = 5 * time.Second
+)
+
+//不是幂等的方法，请求可能已经被节点处理，失败时不重试
+var nonIdempotentMethods = map[string]bool{
+	"author_submitExtrinsic":         true,
+	"author_submitAndWatchExtrinsic": true,
+	"author_insertKey":               true,
+	"author_rotateKeys":              true,
+}
+
+//所有的RpcClient共用一个http.Client，复用tcp连接
+var defaultHttpClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   32,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	},
+}
+
 type RpcClient struct {
 	rpcUrl      string
 	rpcUser     string
 	rpcPassword string
+	httpClient  *http.Client
+	timeout     time.Duration
+	retry       RetryConfig
+}
+
+/*
+RetryConfig 网络错误和http 5xx时的重试策略
+每次重试的等待时间从MinBackoff开始翻倍，最大为MaxBackoff
+提交交易等不是幂等的方法不重试
+*/
+type RetryConfig struct {
+	MaxRetries int
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
 }
 
 type RequestBody struct {
@@ -31,9 +78,9 @@ type RespBody struct {
 	Id      int         `json:"id"`
 }
 type RespErrorBody struct {
-	JsonRpc string                 `json:"jsonrpc"`
-	Error   map[string]interface{} `json:"error"`
-	Id      int                    `json:"id"`
+	JsonRpc string    `json:"jsonrpc"`
+	Error   *RPCError `json:"error"`
+	Id      int       `json:"id"`
 }
 
 //初始化一个rpc客户端
@@ -42,89 +89,261 @@ func New(url, user, password string) *RpcClient {
 		rpcUrl:      url,
 		rpcUser:     user,
 		rpcPassword: password,
+		httpClient:  defaultHttpClient,
+		timeout:     defaultCallTimeout,
+	}
+}
+
+//设置每次调用的超时时间，ctx中已经有deadline时不生效，0表示不设置超时
+func (rpc *RpcClient) SetTimeout(timeout time.Duration) {
+	rpc.timeout = timeout
+}
+
+//设置重试策略，默认不重试
+func (rpc *RpcClient) SetRetry(retry RetryConfig) {
+	if retry.MinBackoff <= 0 {
+		retry.MinBackoff = defaultMinBackoff
 	}
+	if retry.MaxBackoff < retry.MinBackoff {
+		retry.MaxBackoff = defaultMaxBackoff
+	}
+	rpc.retry = retry
+}
+
+//替换默认共用的http.Client
+func (rpc *RpcClient) SetHttpClient(client *http.Client) {
+	rpc.httpClient = client
+}
+
+func (rpc *RpcClient) Call(method string, params []interface{}) ([]byte, error) {
+	return rpc.SendRequestContext(context.Background(), method, params)
+}
+
+func (rpc *RpcClient) CallContext(ctx context.Context, method string, params []interface{}) ([]byte, error) {
+	return rpc.SendRequestContext(ctx, method, params)
+}
+
+func (rpc *RpcClient) BatchCall(requests []Request) ([]BatchResult, error) {
+	return rpc.SendBatchContext(context.Background(), requests)
+}
+
+func (rpc *RpcClient) BatchCallContext(ctx context.Context, requests []Request) ([]BatchResult, error) {
+	return rpc.SendBatchContext(ctx, requests)
+}
+
+func (rpc *RpcClient) Subscribe(method, unsubscribeMethod string, params []interface{}) (*Subscription, error) {
+	return nil, ErrNotSupportSubscribe
+}
+
+func (rpc *RpcClient) Close() error {
+	return nil
 }
 
 func (rpc *RpcClient) SendRequest(method string, params []interface{}) ([]byte, error) {
+	return rpc.SendRequestContext(context.Background(), method, params)
+}
+
+//ctx取消或者超时时立即返回
+func (rpc *RpcClient) SendRequestContext(ctx context.Context, method string, params []interface{}) ([]byte, error) {
 	id := rand.Intn(10000)
-	var (
-		reqBytes []byte
-		err      error
-	)
-	if params != nil {
-		var reqBody RequestBody
-		reqBody.JsonRpc = "2.0"
-		reqBody.Id = id
-		reqBody.Method = method
-		reqBody.Params = params
-		reqBytes, err = json.Marshal(reqBody)
-	} else {
-		var reqBody ReqNotHaveParams
-		reqBody.JsonRpc = "2.0"
-		reqBody.Id = id
-		reqBody.Method = method
-		reqBytes, err = json.Marshal(reqBody)
+	reqBytes, err := newRequestBody(id, method, params)
+	if err != nil {
+		return nil, err
 	}
+	resp, err := rpc.post(ctx, reqBytes, !nonIdempotentMethods[method])
 	if err != nil {
 		return nil, err
 	}
+	return parseResponse(resp)
+}
+
+/*
+使用json-rpc 2.0的批量请求，一次http请求发送多个调用
+返回的结果按id和请求对应，顺序和requests一致
+*/
+func (rpc *RpcClient) SendBatch(requests []Request) ([]BatchResult, error) {
+	return rpc.SendBatchContext(context.Background(), requests)
+}
+
+func (rpc *RpcClient) SendBatchContext(ctx context.Context, requests []Request) ([]BatchResult, error) {
+	if len(requests) == 0 {
+		return nil, nil
+	}
+	baseId := rand.Intn(10000)
+	ids := make([]int, len(requests))
+	for i := range requests {
+		ids[i] = baseId + i
+	}
+	reqBytes, err := newBatchBody(ids, requests)
+	if err != nil {
+		return nil, err
+	}
+	retry := true
+	for _, request := range requests {
+		if nonIdempotentMethods[request.Method] {
+			retry = false
+		}
+	}
+	resp, err := rpc.post(ctx, reqBytes, retry)
+	if err != nil {
+		return nil, err
+	}
+	return parseBatchResponse(ids, resp)
+}
+
+//发送请求，retry为true时网络错误和5xx按照重试策略重试
+func (rpc *RpcClient) post(ctx context.Context, reqBytes []byte, retry bool) ([]byte, error) {
+	if _, ok := ctx.Deadline(); !ok && rpc.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, rpc.timeout)
+		defer cancel()
+	}
+	backoff := rpc.retry.MinBackoff
+	for attempt := 0; ; attempt++ {
+		resp, retryable, err := rpc.doPost(ctx, reqBytes)
+		if err == nil {
+			return resp, nil
+		}
+		if !retry || !retryable || attempt >= rpc.retry.MaxRetries || ctx.Err() != nil {
+			return nil, err
+		}
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, err
+		case <-timer.C:
+		}
+		backoff *= 2
+		if backoff > rpc.retry.MaxBackoff {
+			backoff = rpc.retry.MaxBackoff
+		}
+	}
+}
+
+func (rpc *RpcClient) doPost(ctx context.Context, reqBytes []byte) ([]byte, bool, error) {
 	reqBuf := bytes.NewBuffer(reqBytes)
 	var (
 		req *http.Request
+		err error
 	)
 
 	if req, err = http.NewRequest(http.MethodPost, rpc.rpcUrl, reqBuf); err != nil {
-		return nil, err
+		return nil, false, err
 	}
+	req = req.WithContext(ctx)
 	req.Header.Add("Content-Type", "application/json")
 	//设置rpc的用户和密码
 	//如果为空就不设置
 	if rpc.rpcUser != "" && rpc.rpcPassword != "" {
 		req.SetBasicAuth(rpc.rpcUser, rpc.rpcPassword)
 	}
-	client := &http.Client{}
-	res, err := client.Do(req)
+	res, err := rpc.httpClient.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, true, err
 	}
 	defer res.Body.Close()
 
 	resp, err := ioutil.ReadAll(res.Body)
 	//fmt.Println(string(resp))
 	if err != nil {
-		return nil, err
+		return nil, true, err
+	}
+	if res.StatusCode >= http.StatusInternalServerError {
+		return nil, true, fmt.Errorf("http status error,Code=%d,Data=[%s]", res.StatusCode, string(resp))
+	}
+	return resp, false, nil
+}
+
+func newRequestBody(id int, method string, params []interface{}) ([]byte, error) {
+	return json.Marshal(newRequest(id, method, params))
+}
+
+func newBatchBody(ids []int, requests []Request) ([]byte, error) {
+	batch := make([]interface{}, len(requests))
+	for i, req := range requests {
+		batch[i] = newRequest(ids[i], req.Method, req.Params)
+	}
+	return json.Marshal(batch)
+}
+
+func newRequest(id int, method string, params []interface{}) interface{} {
+	if params != nil {
+		var reqBody RequestBody
+		reqBody.JsonRpc = "2.0"
+		reqBody.Id = id
+		reqBody.Method = method
+		reqBody.Params = params
+		return reqBody
+	}
+	var reqBody ReqNotHaveParams
+	reqBody.JsonRpc = "2.0"
+	reqBody.Id = id
+	reqBody.Method = method
+	return reqBody
+}
+
+//批量请求的返回是一个数组，顺序不一定和请求一致，按id匹配
+func parseBatchResponse(ids []int, resp []byte) ([]BatchResult, error) {
+	var items []json.RawMessage
+	if err := json.Unmarshal(resp, &items); err != nil {
+		//节点不支持批量请求时会返回一个错误对象
+		if _, err1 := parseResponse(resp); err1 != nil {
+			return nil, err1
+		}
+		return nil, fmt.Errorf("Parse batch resp error,Err=【%v】", err)
+	}
+	byId := make(map[int]json.RawMessage, len(items))
+	for _, item := range items {
+		var head struct {
+			Id *int `json:"id"`
+		}
+		if err := json.Unmarshal(item, &head); err != nil || head.Id == nil {
+			continue
+		}
+		byId[*head.Id] = item
+	}
+	results := make([]BatchResult, len(ids))
+	for i, id := range ids {
+		item, ok := byId[id]
+		if !ok {
+			results[i].Error = fmt.Errorf("batch response missing id=%d", id)
+			continue
+		}
+		results[i].Result, results[i].Error = parseResponse(item)
+	}
+	return results, nil
+}
+
+/*
+解析rpc返回的数据，所有的Transport共用
+节点返回错误时返回*RPCError，可以使用errors.As获取
+result为null时返回ErrNullResult，不再返回"null"字符串，例如storage不存在，区块高度超过最新高度
+*/
+func parseResponse(resp []byte) ([]byte, error) {
+	var respError RespErrorBody
+	if err := json.Unmarshal(resp, &respError); err != nil {
+		return nil, errors.New(fmt.Sprintf("Parse resp error,Err=【%v】", err))
+	}
+	if respError.Error != nil {
+		return nil, respError.Error
 	}
-	//解析resp
 	var response RespBody
 	if err := json.Unmarshal(resp, &response); err != nil {
 		return nil, errors.New(fmt.Sprintf("Parse resp error,Err=【%v】", err))
 	}
 	if response.Result == nil {
-		return nil, errors.New("result is null")
+		return nil, ErrNullResult
 	}
 	//如果返回的结果直接是一个string，就不在做json处理了，直接返回
 	switch response.Result.(type) {
 	case string:
 		return []byte(response.Result.(string)), nil
 	default:
-
 		data, err := json.Marshal(response.Result)
 		if err != nil {
 			return nil, errors.New(fmt.Sprintf("Rpc marshal result error,Err=【%v】,Data=[%s]", err, string(resp)))
 		}
-		//处理rpc调用失败的情况
-		if string(data) == "null" {
-			var respError RespErrorBody
-			if err := json.Unmarshal(resp, &respError); err != nil {
-				return nil, errors.New(fmt.Sprintf("Parse resp error code error,Err=【%v】,Data=[%s]", err, string(resp)))
-			}
-			rpcErr := respError.Error
-			if rpcErr != nil {
-
-				return nil, errors.New(fmt.Sprintf("Rpc get error,Code=【%0.f】,Message=【%s】", rpcErr["code"].(float64), rpcErr["message"].(string)))
-			}
-			return nil, fmt.Errorf("Rpc response error,can parse data,Data=[%s]", string(resp))
-		}
 		return data, nil
 	}
 }
@@ -0,0 +1,138 @@
+package util
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type testRequest struct {
+	Id     int           `json:"id"`
+	Method string        `json:"method"`
+	Params []interface{} `json:"params"`
+}
+
+//按method返回结果，批量请求的返回倒序，验证按id匹配
+func testRpcServer(t *testing.T) *httptest.Server {
+	results := map[string]string{
+		"test_string": `"0x01"`,
+		"test_object": `{"specVersion":1}`,
+		"test_null":   `null`,
+	}
+	respond := func(req testRequest) string {
+		if req.Method == "test_error" {
+			return fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"error":{"code":1010,"message":"Invalid Transaction","data":"Transaction is outdated"}}`, req.Id)
+		}
+		result, ok := results[req.Method]
+		if !ok {
+			return fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"error":{"code":-32601,"message":"Method not found"}}`, req.Id)
+		}
+		return fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"result":%s}`, req.Id, result)
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		var batch []testRequest
+		if err := json.Unmarshal(body, &batch); err == nil {
+			w.Write([]byte("["))
+			for i := len(batch) - 1; i >= 0; i-- {
+				w.Write([]byte(respond(batch[i])))
+				if i > 0 {
+					w.Write([]byte(","))
+				}
+			}
+			w.Write([]byte("]"))
+			return
+		}
+		var req testRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Error(err)
+			return
+		}
+		w.Write([]byte(respond(req)))
+	}))
+}
+
+func TestRpcClientCall(t *testing.T) {
+	server := testRpcServer(t)
+	defer server.Close()
+	client := New(server.URL, "", "")
+
+	//string结果直接返回，不做json处理
+	data, err := client.Call("test_string", nil)
+	if err != nil || string(data) != "0x01" {
+		t.Fatalf("test_string result is %s,err=%v", data, err)
+	}
+	data, err = client.Call("test_object", []interface{}{})
+	if err != nil || string(data) != `{"specVersion":1}` {
+		t.Fatalf("test_object result is %s,err=%v", data, err)
+	}
+	if _, err := client.Call("test_null", nil); !errors.Is(err, ErrNullResult) {
+		t.Fatalf("test_null error is %v", err)
+	}
+	_, err = client.Call("test_error", nil)
+	rpcErr, ok := AsRPCError(err)
+	if !ok || rpcErr.Code != ErrCodeInvalidTransaction {
+		t.Fatalf("test_error error is %v", err)
+	}
+	if !IsInvalidTransaction(err) || !IsStaleNonce(err) {
+		t.Fatalf("test_error is not stale nonce: %v", err)
+	}
+	if _, err := client.Call("test_unknown", nil); !IsMethodNotFound(err) {
+		t.Fatalf("test_unknown error is %v", err)
+	}
+}
+
+func TestRpcClientBatchCall(t *testing.T) {
+	server := testRpcServer(t)
+	defer server.Close()
+	client := New(server.URL, "", "")
+
+	results, err := client.BatchCall([]Request{
+		{Method: "test_string"},
+		{Method: "test_null", Params: []interface{}{"0x00"}},
+		{Method: "test_error"},
+		{Method: "test_object"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("batch results length is %d", len(results))
+	}
+	if results[0].Error != nil || string(results[0].Result) != "0x01" {
+		t.Fatalf("result 0 is %s,err=%v", results[0].Result, results[0].Error)
+	}
+	if !errors.Is(results[1].Error, ErrNullResult) {
+		t.Fatalf("result 1 error is %v", results[1].Error)
+	}
+	if !IsInvalidTransaction(results[2].Error) {
+		t.Fatalf("result 2 error is %v", results[2].Error)
+	}
+	if results[3].Error != nil || string(results[3].Result) != `{"specVersion":1}` {
+		t.Fatalf("result 3 is %s,err=%v", results[3].Result, results[3].Error)
+	}
+}
+
+func TestParseBatchResponse(t *testing.T) {
+	//缺少的id返回错误，不影响其他结果
+	results, err := parseBatchResponse([]int{1, 2}, []byte(`[{"jsonrpc":"2.0","id":2,"result":"0x02"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Error == nil || string(results[1].Result) != "0x02" {
+		t.Fatalf("results are %+v", results)
+	}
+	//节点不支持批量请求时返回一个错误对象
+	_, err = parseBatchResponse([]int{1}, []byte(`{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"Invalid request"}}`))
+	if rpcErr, ok := AsRPCError(err); !ok || rpcErr.Code != -32600 {
+		t.Fatalf("batch error is %v", err)
+	}
+}
@@ -0,0 +1,366 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const streamCallTimeout = defaultCallTimeout
+
+//streamConn 一个双向的消息连接，websocket和ipc各自实现
+type streamConn interface {
+	ReadMessage() ([]byte, error)
+	WriteMessage(data []byte) error
+	Close() error
+}
+
+/*
+streamClient 基于长连接的rpc客户端，websocket和ipc共用
+每个请求使用自增的id，返回的数据根据id分发给对应的调用者，所以可以并发调用
+连接断开后，正在等待的请求返回错误，下一次调用时自动重连
+有订阅时立即重连并重新订阅，重新订阅被节点拒绝时订阅结束，错误通过Subscription.Err返回
+*/
+type streamClient struct {
+	dial func() (streamConn, error)
+
+	mu      sync.Mutex
+	conn    streamConn
+	pending map[int]*streamCall
+	subs    map[string]*Subscription
+	nextId  int
+	closed  bool
+	timeout time.Duration
+
+	writeMu sync.Mutex
+}
+
+type streamCall struct {
+	respCh chan []byte
+	sub    *Subscription //订阅请求，收到订阅id时注册
+}
+
+type streamMessage struct {
+	Id     *int            `json:"id"`
+	Method string          `json:"method"`
+	Result json.RawMessage `json:"result"`
+	Params struct {
+		Subscription json.RawMessage `json:"subscription"`
+		Result       json.RawMessage `json:"result"`
+	} `json:"params"`
+}
+
+func newStreamClient(dial func() (streamConn, error)) *streamClient {
+	return &streamClient{
+		dial:    dial,
+		pending: make(map[int]*streamCall),
+		subs:    make(map[string]*Subscription),
+		timeout: streamCallTimeout,
+	}
+}
+
+//设置每次调用的超时时间，ctx中已经有deadline时不生效，0表示不设置超时，需要在调用前设置
+func (sc *streamClient) SetTimeout(timeout time.Duration) {
+	sc.timeout = timeout
+}
+
+func (sc *streamClient) Call(method string, params []interface{}) ([]byte, error) {
+	return sc.call(context.Background(), method, params, nil)
+}
+
+func (sc *streamClient) CallContext(ctx context.Context, method string, params []interface{}) ([]byte, error) {
+	return sc.call(ctx, method, params, nil)
+}
+
+func (sc *streamClient) BatchCall(requests []Request) ([]BatchResult, error) {
+	return sc.BatchCallContext(context.Background(), requests)
+}
+
+//批量请求作为一个数组发送，返回的结果按id分发
+func (sc *streamClient) BatchCallContext(ctx context.Context, requests []Request) ([]BatchResult, error) {
+	if len(requests) == 0 {
+		return nil, nil
+	}
+	calls := make([]*streamCall, len(requests))
+	for i := range calls {
+		calls[i] = &streamCall{respCh: make(chan []byte, 1)}
+	}
+	conn, ids, err := sc.register(calls...)
+	if err != nil {
+		return nil, err
+	}
+	defer sc.unregister(ids...)
+
+	reqBytes, err := newBatchBody(ids, requests)
+	if err != nil {
+		return nil, err
+	}
+	if err = sc.write(conn, reqBytes); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := sc.withTimeout(ctx)
+	defer cancel()
+	results := make([]BatchResult, len(requests))
+	for i, call := range calls {
+		select {
+		case resp, ok := <-call.respCh:
+			if !ok {
+				results[i].Error = fmt.Errorf("rpc connection lost,method=%s", requests[i].Method)
+				continue
+			}
+			results[i].Result, results[i].Error = parseResponse(resp)
+		case <-ctx.Done():
+			return nil, fmt.Errorf("rpc batch request error,err=%v", ctx.Err())
+		}
+	}
+	return results, nil
+}
+
+/*
+订阅节点的推送，例如 chain_subscribeNewHead
+unsubscribeMethod 是取消订阅的方法名，例如 chain_unsubscribeNewHead
+*/
+func (sc *streamClient) Subscribe(method, unsubscribeMethod string, params []interface{}) (*Subscription, error) {
+	sub := newSubscription(sc, unsubscribeMethod)
+	sub.method, sub.params = method, params
+	if _, err := sc.call(context.Background(), method, params, sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+//关闭连接，关闭后不再重连
+func (sc *streamClient) Close() error {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.closed = true
+	if sc.conn == nil {
+		return nil
+	}
+	return sc.conn.Close()
+}
+
+func (sc *streamClient) call(ctx context.Context, method string, params []interface{}, sub *Subscription) ([]byte, error) {
+	respCh := make(chan []byte, 1)
+	conn, ids, err := sc.register(&streamCall{respCh: respCh, sub: sub})
+	if err != nil {
+		return nil, err
+	}
+	id := ids[0]
+	defer sc.unregister(id)
+
+	reqBytes, err := newRequestBody(id, method, params)
+	if err != nil {
+		return nil, err
+	}
+	if err = sc.write(conn, reqBytes); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := sc.withTimeout(ctx)
+	defer cancel()
+	select {
+	case resp, ok := <-respCh:
+		if !ok {
+			return nil, fmt.Errorf("rpc connection lost,method=%s", method)
+		}
+		return parseResponse(resp)
+	case <-ctx.Done():
+		return nil, fmt.Errorf("rpc request error,method=%s,err=%v", method, ctx.Err())
+	}
+}
+
+//ctx中没有deadline时使用设置的超时时间
+func (sc *streamClient) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || sc.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, sc.timeout)
+}
+
+//分配请求id，如果连接已经断开就重新连接
+func (sc *streamClient) register(calls ...*streamCall) (streamConn, []int, error) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if sc.closed {
+		return nil, nil, ErrClientClosed
+	}
+	if sc.conn == nil {
+		conn, err := sc.dial()
+		if err != nil {
+			return nil, nil, err
+		}
+		sc.conn = conn
+		go sc.readLoop(conn)
+	}
+	ids := make([]int, len(calls))
+	for i, call := range calls {
+		sc.nextId++
+		ids[i] = sc.nextId
+		sc.pending[ids[i]] = call
+	}
+	return sc.conn, ids, nil
+}
+
+func (sc *streamClient) unregister(ids ...int) {
+	sc.mu.Lock()
+	for _, id := range ids {
+		delete(sc.pending, id)
+	}
+	sc.mu.Unlock()
+}
+
+func (sc *streamClient) write(conn streamConn, data []byte) error {
+	sc.writeMu.Lock()
+	defer sc.writeMu.Unlock()
+	if err := conn.WriteMessage(data); err != nil {
+		conn.Close()
+		return fmt.Errorf("write rpc message error,err=%v", err)
+	}
+	return nil
+}
+
+//读取节点返回的数据，按id分发
+func (sc *streamClient) readLoop(conn streamConn) {
+	for {
+		data, err := conn.ReadMessage()
+		if err != nil {
+			sc.dropConn(conn)
+			return
+		}
+		//批量请求返回的是一个数组
+		if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+			var items []json.RawMessage
+			if err := json.Unmarshal(trimmed, &items); err != nil {
+				continue
+			}
+			for _, item := range items {
+				sc.dispatch(item)
+			}
+			continue
+		}
+		sc.dispatch(data)
+	}
+}
+
+func (sc *streamClient) dispatch(data []byte) {
+	var msg streamMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return
+	}
+	if msg.Id == nil {
+		//订阅推送的数据
+		if len(msg.Params.Subscription) == 0 {
+			return
+		}
+		sc.mu.Lock()
+		sub, ok := sc.subs[string(msg.Params.Subscription)]
+		sc.mu.Unlock()
+		if ok {
+			sub.deliver(msg.Params.Result)
+		}
+		return
+	}
+	sc.mu.Lock()
+	call, ok := sc.pending[*msg.Id]
+	if ok {
+		delete(sc.pending, *msg.Id)
+		//在处理下一条消息之前注册订阅，避免丢失推送
+		if call.sub != nil && len(msg.Result) > 0 && string(msg.Result) != "null" {
+			call.sub.id = string(msg.Result)
+			sc.subs[call.sub.id] = call.sub
+		}
+	}
+	sc.mu.Unlock()
+	if ok {
+		call.respCh <- data
+	}
+}
+
+//连接断开，通知所有等待中的请求，有订阅时重新连接并重新订阅
+func (sc *streamClient) dropConn(conn streamConn) {
+	conn.Close()
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if sc.conn != conn {
+		return
+	}
+	sc.conn = nil
+	for id, call := range sc.pending {
+		close(call.respCh)
+		delete(sc.pending, id)
+	}
+	var subs []*Subscription
+	for id, sub := range sc.subs {
+		delete(sc.subs, id)
+		sub.id = ""
+		if sc.closed {
+			sub.finish(ErrSubscriptionLost)
+			continue
+		}
+		subs = append(subs, sub)
+	}
+	if len(subs) > 0 {
+		go sc.resubscribe(subs)
+	}
+}
+
+/*
+重新订阅，连接失败时按退避时间一直重试，直到客户端关闭或者订阅被取消
+节点拒绝订阅时结束订阅，错误通过Err返回
+*/
+func (sc *streamClient) resubscribe(subs []*Subscription) {
+	backoff := defaultMinBackoff
+	for {
+		var retry []*Subscription
+		for _, sub := range subs {
+			select {
+			case <-sub.quit:
+				continue
+			default:
+			}
+			_, err := sc.call(context.Background(), sub.method, sub.params, sub)
+			if err == nil {
+				//重新订阅的过程中被取消
+				select {
+				case <-sub.quit:
+					sub.release()
+				default:
+				}
+				continue
+			}
+			if errors.Is(err, ErrClientClosed) {
+				sub.finish(ErrSubscriptionLost)
+				continue
+			}
+			if _, ok := AsRPCError(err); ok || errors.Is(err, ErrNullResult) {
+				sub.finish(fmt.Errorf("resubscribe %s error,err=%w", sub.method, err))
+				continue
+			}
+			retry = append(retry, sub)
+		}
+		if len(retry) == 0 {
+			return
+		}
+		subs = retry
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > defaultMaxBackoff {
+			backoff = defaultMaxBackoff
+		}
+	}
+}
+
+func (sc *streamClient) removeSubscription(sub *Subscription) string {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	id := sub.id
+	if id != "" && sc.subs[id] == sub {
+		delete(sc.subs, id)
+	}
+	return id
+}
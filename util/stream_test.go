@@ -0,0 +1,193 @@
+package util
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+//fakeConn 内存中的连接，收到订阅请求时返回自增的订阅id
+type fakeConn struct {
+	server *fakeServer
+	in     chan []byte
+	closed chan struct{}
+	once   sync.Once
+}
+
+type fakeServer struct {
+	mu     sync.Mutex
+	conns  []*fakeConn
+	nextId int
+	reject bool //拒绝订阅请求
+	subIds chan string
+}
+
+func newFakeServer() *fakeServer {
+	return &fakeServer{subIds: make(chan string, 16)}
+}
+
+func (s *fakeServer) dial() (streamConn, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	conn := &fakeConn{server: s, in: make(chan []byte, 16), closed: make(chan struct{})}
+	s.conns = append(s.conns, conn)
+	return conn, nil
+}
+
+func (s *fakeServer) conn() *fakeConn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conns[len(s.conns)-1]
+}
+
+func (c *fakeConn) ReadMessage() ([]byte, error) {
+	select {
+	case data := <-c.in:
+		return data, nil
+	case <-c.closed:
+		return nil, errors.New("connection closed")
+	}
+}
+
+func (c *fakeConn) WriteMessage(data []byte) error {
+	var req struct {
+		Id     int    `json:"id"`
+		Method string `json:"method"`
+	}
+	if err := json.Unmarshal(data, &req); err != nil {
+		return err
+	}
+	s := c.server
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case req.Method == "test_subscribe" && s.reject:
+		c.in <- []byte(fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"error":{"code":-32000,"message":"rejected"}}`, req.Id))
+	case req.Method == "test_subscribe":
+		s.nextId++
+		id := fmt.Sprintf("sub-%d", s.nextId)
+		c.in <- []byte(fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"result":"%s"}`, req.Id, id))
+		s.subIds <- id
+	default:
+		c.in <- []byte(fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"result":true}`, req.Id))
+	}
+	return nil
+}
+
+func (c *fakeConn) Close() error {
+	c.once.Do(func() { close(c.closed) })
+	return nil
+}
+
+func (c *fakeConn) notify(subId, result string) {
+	c.in <- []byte(fmt.Sprintf(`{"jsonrpc":"2.0","method":"test_notify","params":{"subscription":"%s","result":%s}}`, subId, result))
+}
+
+func waitSubId(t *testing.T, s *fakeServer) string {
+	select {
+	case id := <-s.subIds:
+		return id
+	case <-time.After(2 * time.Second):
+		t.Fatal("wait subscribe timeout")
+	}
+	return ""
+}
+
+func waitNotification(t *testing.T, sub *Subscription, expect string) {
+	select {
+	case data := <-sub.Notifications():
+		if string(data) != expect {
+			t.Fatalf("notification is %s,expect %s", data, expect)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("wait notification timeout")
+	}
+}
+
+func waitErr(t *testing.T, sub *Subscription) error {
+	select {
+	case err := <-sub.Err():
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("wait subscription error timeout")
+	}
+	return nil
+}
+
+func TestStreamResubscribe(t *testing.T) {
+	server := newFakeServer()
+	sc := newStreamClient(server.dial)
+	sub, err := sc.Subscribe("test_subscribe", "test_unsubscribe", []interface{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := waitSubId(t, server)
+	server.conn().notify(id, "1")
+	waitNotification(t, sub, "1")
+
+	//连接断开后重新订阅，使用新的订阅id
+	server.conn().Close()
+	id = waitSubId(t, server)
+	if id != "sub-2" {
+		t.Fatalf("resubscribe id is %s", id)
+	}
+	server.conn().notify(id, "2")
+	waitNotification(t, sub, "2")
+
+	sc.Close()
+	if err := waitErr(t, sub); err != ErrSubscriptionLost {
+		t.Fatalf("subscription error is %v", err)
+	}
+}
+
+func TestStreamResubscribeRejected(t *testing.T) {
+	server := newFakeServer()
+	sc := newStreamClient(server.dial)
+	defer sc.Close()
+	sub, err := sc.Subscribe("test_subscribe", "test_unsubscribe", []interface{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitSubId(t, server)
+	server.mu.Lock()
+	server.reject = true
+	server.mu.Unlock()
+	server.conn().Close()
+	err = waitErr(t, sub)
+	if _, ok := AsRPCError(err); !ok {
+		t.Fatalf("subscription error is %v", err)
+	}
+	select {
+	case <-sub.Done():
+	case <-time.After(time.Second):
+		t.Fatal("subscription is not done")
+	}
+}
+
+func TestSubscriptionOverflow(t *testing.T) {
+	server := newFakeServer()
+	sc := newStreamClient(server.dial)
+	defer sc.Close()
+	sub, err := sc.Subscribe("test_subscribe", "test_unsubscribe", []interface{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := waitSubId(t, server)
+	for i := 0; i <= cap(sub.notifyCh); i++ {
+		sub.deliver(json.RawMessage("1"))
+	}
+	if err := waitErr(t, sub); err != ErrSubscriptionOverflow {
+		t.Fatalf("subscription error is %v", err)
+	}
+	//取消订阅后不再分发推送
+	time.Sleep(50 * time.Millisecond)
+	sc.mu.Lock()
+	_, ok := sc.subs[id]
+	sc.mu.Unlock()
+	if ok {
+		t.Fatal("subscription is not removed")
+	}
+}
@@ -0,0 +1,108 @@
+package util
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+/*
+Subscription 节点推送的订阅
+Notifications 返回推送的原始数据
+websocket和ipc连接断开后自动重连并重新订阅，断开期间的推送会丢失
+Err 在订阅无法继续时返回一个错误，例如客户端关闭或者重新订阅失败，订阅结束后关闭
+*/
+type Subscription struct {
+	client            subscriber
+	id                string //stream客户端中由streamClient.mu保护，重新订阅后会变化
+	method            string
+	params            []interface{}
+	unsubscribeMethod string
+
+	notifyCh chan json.RawMessage
+	errCh    chan error
+	quit     chan struct{}
+	once     sync.Once
+}
+
+//subscriber 创建订阅的客户端
+type subscriber interface {
+	Call(method string, params []interface{}) ([]byte, error)
+	//移除订阅，返回节点分配的订阅id
+	removeSubscription(sub *Subscription) string
+}
+
+func newSubscription(client subscriber, unsubscribeMethod string) *Subscription {
+	return &Subscription{
+		client:            client,
+		unsubscribeMethod: unsubscribeMethod,
+		notifyCh:          make(chan json.RawMessage, 64),
+		errCh:             make(chan error, 1),
+		quit:              make(chan struct{}),
+	}
+}
+
+func (s *Subscription) Notifications() <-chan json.RawMessage {
+	return s.notifyCh
+}
+
+func (s *Subscription) Err() <-chan error {
+	return s.errCh
+}
+
+//订阅结束后关闭
+func (s *Subscription) Done() <-chan struct{} {
+	return s.quit
+}
+
+//取消订阅，可以重复调用
+func (s *Subscription) Unsubscribe() error {
+	var unsubscribe bool
+	s.once.Do(func() {
+		unsubscribe = true
+		close(s.errCh)
+		close(s.quit)
+	})
+	if !unsubscribe {
+		return nil
+	}
+	return s.release()
+}
+
+//从客户端中移除订阅，并通知节点取消订阅
+func (s *Subscription) release() error {
+	id := s.client.removeSubscription(s)
+	if s.unsubscribeMethod == "" || id == "" {
+		return nil
+	}
+	_, err := s.client.Call(s.unsubscribeMethod, []interface{}{json.RawMessage(id)})
+	return err
+}
+
+/*
+在连接的读取goroutine中调用，不能阻塞
+缓冲区满了说明推送没有被及时读取，结束订阅并返回ErrSubscriptionOverflow
+*/
+func (s *Subscription) deliver(data json.RawMessage) {
+	select {
+	case <-s.quit:
+		return
+	default:
+	}
+	select {
+	case s.notifyCh <- data:
+	default:
+		s.finish(ErrSubscriptionOverflow)
+		//release会获取客户端的锁并发送请求，不能在读取goroutine中调用
+		go s.release()
+	}
+}
+
+func (s *Subscription) finish(err error) {
+	s.once.Do(func() {
+		if err != nil {
+			s.errCh <- err
+		}
+		close(s.errCh)
+		close(s.quit)
+	})
+}
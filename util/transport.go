@@ -0,0 +1,50 @@
+package util
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrClientClosed = errors.New("rpc client is closed")
+	//客户端已经关闭，订阅不能继续
+	ErrSubscriptionLost = errors.New("rpc connection lost,subscription is closed")
+	//订阅的推送没有被及时读取，缓冲区满了
+	ErrSubscriptionOverflow = errors.New("subscription buffer is full,subscription is closed")
+	ErrNotSupportSubscribe  = errors.New("transport do not support subscription")
+	//节点返回的result为null，例如storage不存在
+	ErrNullResult = errors.New("result is null")
+)
+
+/*
+Transport rpc.Client依赖的传输层
+RpcClient(http)，WsClient(websocket)，IpcClient(unix socket)和MockTransport(测试)都实现了这个接口
+Call和BatchCall在节点返回的result为null时返回ErrNullResult，调用者需要用errors.Is判断
+*/
+type Transport interface {
+	Call(method string, params []interface{}) ([]byte, error)
+	CallContext(ctx context.Context, method string, params []interface{}) ([]byte, error)
+	BatchCall(requests []Request) ([]BatchResult, error)
+	BatchCallContext(ctx context.Context, requests []Request) ([]BatchResult, error)
+	Subscribe(method, unsubscribeMethod string, params []interface{}) (*Subscription, error)
+	Close() error
+}
+
+type Request struct {
+	Method string        `json:"method"`
+	Params []interface{} `json:"params"`
+}
+
+//批量请求中每个请求的结果，和请求的顺序一致
+type BatchResult struct {
+	Result []byte
+	Error  error
+}
+
+func batchCallOneByOne(ctx context.Context, t Transport, requests []Request) ([]BatchResult, error) {
+	results := make([]BatchResult, len(requests))
+	for i, req := range requests {
+		results[i].Result, results[i].Error = t.CallContext(ctx, req.Method, req.Params)
+	}
+	return results, nil
+}
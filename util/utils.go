@@ -18,25 +18,75 @@ func AppendBytes(data1, data2 []byte) []byte {
 	return append(data1, data2...)
 }
 
+/*
+根据metadata中hasher的名字返回hash.Hash
+Concat的hasher在hash后面拼接写入的原始数据，Identity直接返回写入的数据
+*/
 func SelectHash(method string) (hash.Hash, error) {
 	switch method {
 	case "Twox128":
 		return xxhash.New128(nil), nil
+	case "Twox256":
+		return xxhash.New256(nil), nil
 	case "Blake2_256":
 		return blake2b.New256(nil)
 	case "Blake2_128":
 		return blake2b.New(16, nil)
 	case "Blake2_128Concat":
-		return blake2b.New(16, nil)
+		h, err := blake2b.New(16, nil)
+		if err != nil {
+			return nil, err
+		}
+		return &concatHash{Hash: h}, nil
 	case "Twox64Concat":
-		return xxhash.New64(nil), nil
+		return xxhash.New64Concat(nil), nil
 	case "Identity":
-		return nil, nil
+		return &concatHash{}, nil
 	default:
 		return nil, errors.New("unknown hash method")
+	}
+}
 
+//Hash为nil时就是Identity
+type concatHash struct {
+	hash.Hash
+	data []byte
+}
+
+func (h *concatHash) Write(p []byte) (int, error) {
+	h.data = append(h.data, p...)
+	if h.Hash == nil {
+		return len(p), nil
 	}
+	return h.Hash.Write(p)
+}
 
+func (h *concatHash) Sum(b []byte) []byte {
+	if h.Hash != nil {
+		b = h.Hash.Sum(b)
+	}
+	return append(b, h.data...)
+}
+
+func (h *concatHash) Reset() {
+	h.data = nil
+	if h.Hash != nil {
+		h.Hash.Reset()
+	}
+}
+
+func (h *concatHash) Size() int {
+	if h.Hash == nil {
+		return len(h.data)
+	}
+	return h.Hash.Size() + len(h.data)
+}
+
+func (h *concatHash) BlockSize() int {
+	if h.Hash == nil {
+		return 1
+	}
+	return h.Hash.BlockSize()
 }
 
 func RemoveHex0x(hexStr string) string {
@@ -0,0 +1,57 @@
+package util
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestSelectHash(t *testing.T) {
+	//hash("chainx")
+	tests := map[string]string{
+		"Twox128":          "8c11ed7502136933cbf1e04fd6f58df8",
+		"Twox256":          "8c11ed7502136933cbf1e04fd6f58df8caba518bc5949bc3acf465a2f9537a2f",
+		"Twox64Concat":     "8c11ed7502136933636861696e78",
+		"Blake2_128":       "3c75396c1696e8ef1db52980db9b0e3a",
+		"Blake2_256":       "d2ac965a83efd5cddf01ca809c4a808b07431befe12d11cb24b7691c36113007",
+		"Blake2_128Concat": "3c75396c1696e8ef1db52980db9b0e3a636861696e78",
+		"Identity":         "636861696e78",
+	}
+	for method, expect := range tests {
+		h, err := SelectHash(method)
+		if err != nil {
+			t.Errorf("%s: %v", method, err)
+			continue
+		}
+		//分两次写入，Concat的hasher需要拼接所有写入的数据
+		h.Write([]byte("chain"))
+		h.Write([]byte("x"))
+		if sum := hex.EncodeToString(h.Sum(nil)); sum != expect {
+			t.Errorf("%s is %s,expect %s", method, sum, expect)
+		}
+		if h.Size() != len(expect)/2 {
+			t.Errorf("%s size is %d,expect %d", method, h.Size(), len(expect)/2)
+		}
+		h.Reset()
+		h.Write([]byte("chainx"))
+		if sum := hex.EncodeToString(h.Sum(nil)); sum != expect {
+			t.Errorf("%s after reset is %s,expect %s", method, sum, expect)
+		}
+	}
+	if _, err := SelectHash("Keccak256"); err == nil {
+		t.Error("unknown hasher: expect error")
+	}
+}
+
+func TestTwox128KnownKeys(t *testing.T) {
+	for data, expect := range map[string]string{
+		"System":    "26aa394eea5630e07c48ae0c9558cef7",
+		"Events":    "80d41e5e16056765bc8461851072c9d7",
+		"Timestamp": "f0c365c3cf59d671eb72da0e7a4113c4",
+	} {
+		h, _ := SelectHash("Twox128")
+		h.Write([]byte(data))
+		if sum := hex.EncodeToString(h.Sum(nil)); sum != expect {
+			t.Errorf("twox128(%s) is %s,expect %s", data, sum, expect)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package util
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	wsDialTimeout  = 10 * time.Second
+	wsWriteTimeout = 10 * time.Second
+)
+
+//WsClient 使用websocket连接节点，连接在第一次请求时建立
+type WsClient struct {
+	*streamClient
+}
+
+type wsConn struct {
+	conn *websocket.Conn
+}
+
+//初始化一个websocket客户端
+func NewWsClient(url, user, password string) *WsClient {
+	header := http.Header{}
+	if user != "" && password != "" {
+		auth := base64.StdEncoding.EncodeToString([]byte(user + ":" + password))
+		header.Set("Authorization", "Basic "+auth)
+	}
+	dial := func() (streamConn, error) {
+		dialer := websocket.Dialer{
+			Proxy:            http.ProxyFromEnvironment,
+			HandshakeTimeout: wsDialTimeout,
+		}
+		conn, _, err := dialer.Dial(url, header)
+		if err != nil {
+			return nil, fmt.Errorf("dial websocket error,url=%s,err=%v", url, err)
+		}
+		return &wsConn{conn: conn}, nil
+	}
+	return &WsClient{streamClient: newStreamClient(dial)}
+}
+
+//兼容RpcClient的调用方式
+func (ws *WsClient) SendRequest(method string, params []interface{}) ([]byte, error) {
+	return ws.Call(method, params)
+}
+
+func (wc *wsConn) ReadMessage() ([]byte, error) {
+	_, data, err := wc.conn.ReadMessage()
+	return data, err
+}
+
+func (wc *wsConn) WriteMessage(data []byte) error {
+	wc.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	return wc.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+func (wc *wsConn) Close() error {
+	return wc.conn.Close()
+}